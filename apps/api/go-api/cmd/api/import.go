@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/configs"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/database"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+// runImport implements the import subcommand, which loads another
+// shortener's export into a user's account:
+//
+//	api import -format bitly -user 42 [-commit] export.csv
+//
+// Without -commit it only reports what the import would do.
+func runImport(config *configs.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", services.ImportFormatCSV, "export format: csv, bitly, yourls-sql or yourls-json")
+	userID := flags.Uint("user", 0, "ID of the user who will own the links")
+	commit := flags.Bool("commit", false, "create the links instead of doing a dry run")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *userID == 0 {
+		flags.Usage()
+		return errors.New("import needs -user and one export file")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	records, err := services.ParseImport(*format, file)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.GetDB().First(&models.User{}, *userID).Error; err != nil {
+		return fmt.Errorf("failed to find user %d: %v", *userID, err)
+	}
+	urlService, err := newURLService(config, db.GetDB())
+	if err != nil {
+		return err
+	}
+
+	report, err := urlService.ImportURLs(context.Background(), *userID, records, !*commit)
+	if err != nil {
+		return err
+	}
+	printImportReport(report)
+	return nil
+}
+
+// printImportReport lists the records that were not imported, then totals
+func printImportReport(report *models.ImportReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, result := range report.Results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "row %d\t%s\t%s\t%s\n", result.Row, result.Status, result.ShortCode, result.Error)
+		}
+	}
+	w.Flush()
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Dry run: would import"
+	}
+	fmt.Printf("%s %d links; %d conflicts, %d invalid, %d failed\n",
+		verb, report.Imported, report.Conflicts, report.Invalid, report.Failed)
+}
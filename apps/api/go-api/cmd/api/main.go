@@ -1,17 +1,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/refsigregory/refurl/apps/api/go-api/configs"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/database"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/handlers"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/router"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/mailer"
+	"gorm.io/gorm"
 )
 
+// shutdownTimeout bounds how long in-flight requests and queued work may take
+// to finish after a shutdown signal
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	// Load configuration
 	config, err := configs.LoadConfig()
@@ -19,6 +31,19 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(config, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "set-role" {
+		if err := runSetRole(config, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if err := run(config); err != nil {
 		log.Fatal(err)
 	}
@@ -34,22 +59,133 @@ func run(config *configs.Config) error {
 
 	// Initialize services
 	healthService := services.NewHealthService()
-	authService := services.NewAuthService(db.GetDB(), config.JWTSecret)
-	urlService := services.NewURLService(db.GetDB())
+	revocationList := services.NewRevocationList(db.GetDB(), config.RevocationCacheTTL, config.RevocationPruneInterval)
+	revocationList.Start()
+	authOpts := []services.AuthServiceOption{
+		services.WithAccessTokenTTL(config.JWTExpiresIn),
+		services.WithRefreshTokenTTL(config.RefreshTokenTTL),
+		services.WithRevocationList(revocationList),
+	}
+	clickRecorder := services.NewClickRecorder(db.GetDB(), config.ClickQueueSize, config.ClickBatchSize, config.ClickFlushInterval)
+	healthService.RegisterMetrics("clicks", func() interface{} { return clickRecorder.Stats() })
+	redirectCache := services.NewRedirectCache(config.RedirectCacheSize, config.RedirectCacheTTL)
+	healthService.RegisterMetrics("redirect_cache", func() interface{} { return redirectCache.Stats() })
+	urlOpts := []services.URLServiceOption{
+		services.WithClickRecorder(clickRecorder),
+		services.WithRedirectCache(redirectCache),
+	}
+
+	// Email verification needs a way to send email; without SMTP, accounts
+	// are verified as soon as they register
+	if config.SMTPHost != "" {
+		smtpMailer := mailer.NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUser, config.SMTPPass, config.SMTPFrom,
+			config.SMTPTimeout)
+		verifyURL := strings.TrimRight(config.PublicURL, "/") + "/api/auth/verify"
+		authOpts = append(authOpts, services.WithEmailVerifier(
+			services.NewEmailVerifier(config.JWTSecret, config.EmailVerificationTTL, smtpMailer, verifyURL)))
+		urlOpts = append(urlOpts, services.WithUnverifiedLinkLimit(config.UnverifiedLinkLimit))
+	}
+
+	authService := services.NewAuthService(db.GetDB(), config.JWTSecret, authOpts...)
+	urlService, err := newURLService(config, db.GetDB(), urlOpts...)
+	if err != nil {
+		return err
+	}
+	clickRecorder.Start()
+
+	analyticsService := services.NewAnalyticsService(db.GetDB())
+	tagService := services.NewTagService(db.GetDB())
+	folderService := services.NewFolderService(db.GetDB())
+	apiKeyService := services.NewAPIKeyService(db.GetDB())
+	adminService := services.NewAdminService(db.GetDB(), urlService, revocationList)
+	expirySweeper := services.NewExpirySweeper(db.GetDB(), config.ExpirySweepInterval)
+	expirySweeper.Start()
+	trashPurger := services.NewTrashPurger(db.GetDB(), config.TrashRetention, config.TrashPurgeInterval)
+	trashPurger.Start()
+	idempotencyService := services.NewIdempotencyService(db.GetDB(), config.IdempotencyKeyTTL, config.IdempotencyLease)
+	idempotencyPurger := services.NewIdempotencyPurger(db.GetDB(), config.IdempotencyPurgeInterval)
+	idempotencyPurger.Start()
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler(healthService)
 	authHandler := handlers.NewAuthHandler(authService)
-	urlHandler := handlers.NewURLHandler(urlService)
+	trustedProxies, err := handlers.ParseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	urlHandler := handlers.NewURLHandler(urlService, handlers.WithTrustedProxies(trustedProxies))
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	folderHandler := handlers.NewFolderHandler(folderService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	adminHandler := handlers.NewAdminHandler(adminService)
 
 	// Initialize router
-	r := router.NewRouter(healthHandler, authHandler, urlHandler, authService)
+	r := router.NewRouter(healthHandler, authHandler, urlHandler, analyticsHandler, tagHandler, folderHandler, apiKeyHandler,
+		adminHandler, authService, revocationList, idempotencyService, apiKeyService)
 
 	// Initialize your application
 	fmt.Printf("Starting go-api server in %s mode...\n", config.NodeEnv)
 
 	// Start the server
 	port := ":" + config.Port
-	fmt.Printf("Server listening on port %s\n", port)
-	return http.ListenAndServe(port, r)
+	server := &http.Server{Addr: port, Handler: r}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("Server listening on port %s\n", port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Stop taking requests, then drain queued background work
+	fmt.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %v", err)
+	}
+	expirySweeper.Stop()
+	trashPurger.Stop()
+	idempotencyPurger.Stop()
+	revocationList.Stop()
+	if err := clickRecorder.Close(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to drain click queue: %v", err)
+	}
+	return nil
+}
+
+// newURLService builds the URLService configured by config, with opts for
+// the dependencies only the server needs
+func newURLService(config *configs.Config, db *gorm.DB, opts ...services.URLServiceOption) (*services.URLService, error) {
+	generator, err := services.NewShortCodeGenerator(config.ShortCodeStyle, config.ShortCodeLength, config.ShortCodeSalt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize short code generator: %v", err)
+	}
+	if !services.ValidRedirectStatus(config.DefaultRedirectStatus) {
+		return nil, fmt.Errorf("invalid DEFAULT_REDIRECT_STATUS %d: %v", config.DefaultRedirectStatus, services.ErrInvalidRedirectStatus)
+	}
+	aliasPolicy := services.NewAliasPolicy(config.AliasMinLength, config.AliasMaxLength, config.ReservedAliases)
+	linkLock := services.NewLinkLock(config.JWTSecret, config.LinkUnlockTTL,
+		services.NewAttemptLimiter(config.LinkPasswordMaxAttempts, config.LinkPasswordLockout),
+		services.NewAttemptLimiter(config.LinkPasswordMaxAttemptsPerLink, config.LinkPasswordLockout))
+	destinationPolicy := services.NewDestinationPolicy(db, config.AllowedURLSchemes, config.PublicHosts)
+
+	return services.NewURLService(db, append([]services.URLServiceOption{
+		services.WithShortCodeGenerator(generator),
+		services.WithAliasPolicy(aliasPolicy),
+		services.WithLinkLock(linkLock),
+		services.WithDefaultRedirectStatus(config.DefaultRedirectStatus),
+		services.WithDestinationPolicy(destinationPolicy),
+		services.WithTrashRetention(config.TrashRetention),
+	}, opts...)...), nil
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/configs"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/database"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// runSetRole implements the set-role subcommand, which grants or takes away
+// admin access, for instance to create the first admin:
+//
+//	api set-role -email admin@example.com -role admin
+func runSetRole(config *configs.Config, args []string) error {
+	flags := flag.NewFlagSet("set-role", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the user to update")
+	role := flags.String("role", models.RoleAdmin, "role to give the user: user or admin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return errors.New("set-role needs -email")
+	}
+	if *role != models.RoleUser && *role != models.RoleAdmin {
+		return fmt.Errorf("unknown role %q", *role)
+	}
+
+	db, err := database.NewDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	result := db.GetDB().Model(&models.User{}).Where("email = ?", *email).Update("role", *role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no user with email %s", *email)
+	}
+
+	fmt.Printf("%s is now %s\n", *email, *role)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,6 +15,10 @@ type Config struct {
 	LogLevel  string
 	APIPrefix string
 
+	// Addresses or CIDRs of reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers are trusted
+	TrustedProxies []string
+
 	// Database
 	DatabaseURL string
 	DBDriver    string
@@ -25,14 +30,71 @@ type Config struct {
 	DBSSLMode   string
 
 	// JWT
-	JWTSecret    string
-	JWTExpiresIn time.Duration
+	JWTSecret       string
+	JWTExpiresIn    time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Token revocation
+	RevocationCacheTTL      time.Duration
+	RevocationPruneInterval time.Duration
+
+	// Short codes
+	ShortCodeStyle  string
+	ShortCodeLength int
+	ShortCodeSalt   string
+
+	// Custom aliases
+	AliasMinLength  int
+	AliasMaxLength  int
+	ReservedAliases []string
+
+	// Click recording
+	ClickQueueSize     int
+	ClickBatchSize     int
+	ClickFlushInterval time.Duration
+
+	// Redirect cache
+	RedirectCacheSize int
+	RedirectCacheTTL  time.Duration
+
+	// Redirects
+	DefaultRedirectStatus int
+
+	// Destination policy
+	AllowedURLSchemes []string
+	PublicHosts       []string
+
+	// Link expiry
+	ExpirySweepInterval time.Duration
+
+	// Trash
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	// Idempotency keys
+	IdempotencyKeyTTL        time.Duration
+	IdempotencyLease         time.Duration
+	IdempotencyPurgeInterval time.Duration
+
+	// Password-protected links
+	LinkUnlockTTL                  time.Duration
+	LinkPasswordMaxAttempts        int
+	LinkPasswordMaxAttemptsPerLink int
+	LinkPasswordLockout            time.Duration
 
 	// Email
-	SMTPHost string
-	SMTPPort int
-	SMTPUser string
-	SMTPPass string
+	SMTPHost    string
+	SMTPPort    int
+	SMTPUser    string
+	SMTPPass    string
+	SMTPFrom    string
+	SMTPTimeout time.Duration
+
+	// Email verification, enabled when SMTPHost is set. PublicURL is where
+	// the API is reachable, for the links in emails.
+	PublicURL            string
+	EmailVerificationTTL time.Duration
+	UnverifiedLinkLimit  int
 
 	// Initial setup
 	InitialUserPassword string
@@ -46,6 +108,8 @@ func LoadConfig() (*Config, error) {
 		LogLevel:  getEnv("LOG_LEVEL", "info"),
 		APIPrefix: getEnv("API_PREFIX", "/api"),
 
+		TrustedProxies: getEnvAsSlice("TRUSTED_PROXIES", nil),
+
 		// Database
 		DatabaseURL: getEnv("DATABASE_URL", ""),
 		DBDriver:    getEnv("DB_DRIVER", "postgres"),
@@ -57,14 +121,70 @@ func LoadConfig() (*Config, error) {
 		DBSSLMode:   getEnv("DB_SSL_MODE", "disable"),
 
 		// JWT
-		JWTSecret:    getEnv("JWT_SECRET", "your-secret-key"),
-		JWTExpiresIn: getEnvAsDuration("JWT_EXPIRES_IN", 24*time.Hour),
+		JWTSecret:       getEnv("JWT_SECRET", "your-secret-key"),
+		JWTExpiresIn:    getEnvAsDuration("JWT_EXPIRES_IN", 15*time.Minute),
+		RefreshTokenTTL: getEnvAsDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		// Token revocation
+		RevocationCacheTTL:      getEnvAsDuration("REVOCATION_CACHE_TTL", 30*time.Second),
+		RevocationPruneInterval: getEnvAsDuration("REVOCATION_PRUNE_INTERVAL", time.Minute),
+
+		// Short codes
+		ShortCodeStyle:  getEnv("SHORT_CODE_STYLE", "random"),
+		ShortCodeLength: getEnvAsInt("SHORT_CODE_LENGTH", 7),
+		ShortCodeSalt:   getEnv("SHORT_CODE_SALT", "refurl"),
+
+		// Custom aliases
+		AliasMinLength:  getEnvAsInt("ALIAS_MIN_LENGTH", 4),
+		AliasMaxLength:  getEnvAsInt("ALIAS_MAX_LENGTH", 10),
+		ReservedAliases: getEnvAsSlice("RESERVED_ALIASES", nil),
+
+		// Click recording
+		ClickQueueSize:     getEnvAsInt("CLICK_QUEUE_SIZE", 10000),
+		ClickBatchSize:     getEnvAsInt("CLICK_BATCH_SIZE", 100),
+		ClickFlushInterval: getEnvAsDuration("CLICK_FLUSH_INTERVAL", time.Second),
+
+		// Redirect cache
+		RedirectCacheSize: getEnvAsInt("REDIRECT_CACHE_SIZE", 10000),
+		RedirectCacheTTL:  getEnvAsDuration("REDIRECT_CACHE_TTL", 5*time.Minute),
+
+		// Redirects
+		DefaultRedirectStatus: getEnvAsInt("DEFAULT_REDIRECT_STATUS", 302),
+
+		// Destination policy
+		AllowedURLSchemes: getEnvAsSlice("ALLOWED_URL_SCHEMES", []string{"http", "https"}),
+		PublicHosts:       getEnvAsSlice("PUBLIC_HOSTS", nil),
+
+		// Link expiry
+		ExpirySweepInterval: getEnvAsDuration("EXPIRY_SWEEP_INTERVAL", time.Minute),
+
+		// Trash
+		TrashRetention:     getEnvAsDuration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: getEnvAsDuration("TRASH_PURGE_INTERVAL", time.Hour),
+
+		// Idempotency keys
+		IdempotencyKeyTTL:        getEnvAsDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		IdempotencyLease:         getEnvAsDuration("IDEMPOTENCY_LEASE", 5*time.Minute),
+		IdempotencyPurgeInterval: getEnvAsDuration("IDEMPOTENCY_PURGE_INTERVAL", time.Hour),
+
+		// Password-protected links
+		LinkUnlockTTL:                  getEnvAsDuration("LINK_UNLOCK_TTL", time.Hour),
+		LinkPasswordMaxAttempts:        getEnvAsInt("LINK_PASSWORD_MAX_ATTEMPTS", 5),
+		LinkPasswordMaxAttemptsPerLink: getEnvAsInt("LINK_PASSWORD_MAX_ATTEMPTS_PER_LINK", 1000),
+		LinkPasswordLockout:            getEnvAsDuration("LINK_PASSWORD_LOCKOUT", 15*time.Minute),
 
 		// Email
-		SMTPHost: getEnv("SMTP_HOST", ""),
-		SMTPPort: getEnvAsInt("SMTP_PORT", 587),
-		SMTPUser: getEnv("SMTP_USER", ""),
-		SMTPPass: getEnv("SMTP_PASS", ""),
+		SMTPHost:    getEnv("SMTP_HOST", ""),
+		SMTPPort:    getEnvAsInt("SMTP_PORT", 587),
+		SMTPUser:    getEnv("SMTP_USER", ""),
+		SMTPPass:    getEnv("SMTP_PASS", ""),
+		SMTPFrom:    getEnv("SMTP_FROM", "RefURL <no-reply@localhost>"),
+		SMTPTimeout: getEnvAsDuration("SMTP_TIMEOUT", 10*time.Second),
+
+		// Email verification
+		PublicURL:            getEnv("PUBLIC_URL", "http://localhost:8080"),
+		EmailVerificationTTL: getEnvAsDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		UnverifiedLinkLimit:  getEnvAsInt("UNVERIFIED_LINK_LIMIT", 5),
 
 		// Initial setup
 		InitialUserPassword: getEnv("INITIAL_USER_PASSWORD", "admin123"),
@@ -98,6 +218,19 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	if value, exists := os.LookupEnv(key); exists {
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+	return defaultValue
+}
+
 // GetDSN returns the database connection string
 func (c *Config) GetDSN() string {
 	if c.DatabaseURL != "" {
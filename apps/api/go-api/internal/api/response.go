@@ -11,6 +11,8 @@ type Response struct {
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
+	// Meta carries details about Data, such as pagination
+	Meta interface{} `json:"meta,omitempty"`
 }
 
 // JSON sends a JSON response
@@ -28,6 +30,15 @@ func Success(w http.ResponseWriter, data interface{}) {
 	})
 }
 
+// SuccessWithMeta sends a success response with metadata about data
+func SuccessWithMeta(w http.ResponseWriter, data interface{}, meta interface{}) {
+	JSON(w, http.StatusOK, Response{
+		Status: "success",
+		Data:   data,
+		Meta:   meta,
+	})
+}
+
 // Error sends an error response
 func Error(w http.ResponseWriter, status int, message string) {
 	JSON(w, status, Response{
@@ -37,6 +48,16 @@ func Error(w http.ResponseWriter, status int, message string) {
 	})
 }
 
+// ErrorWithData sends an error response carrying additional details
+func ErrorWithData(w http.ResponseWriter, status int, message string, data interface{}) {
+	JSON(w, status, Response{
+		Status:  "error",
+		Data:    data,
+		Error:   message,
+		Message: message,
+	})
+}
+
 // BadRequest sends a 400 Bad Request response
 func BadRequest(w http.ResponseWriter, message string) {
 	Error(w, http.StatusBadRequest, message)
@@ -57,6 +78,22 @@ func NotFound(w http.ResponseWriter, message string) {
 	Error(w, http.StatusNotFound, message)
 }
 
+// Conflict sends a 409 Conflict response with details on the conflict
+func Conflict(w http.ResponseWriter, message string, data interface{}) {
+	ErrorWithData(w, http.StatusConflict, message, data)
+}
+
+// PreconditionFailed sends a 412 Precondition Failed response
+func PreconditionFailed(w http.ResponseWriter, message string) {
+	Error(w, http.StatusPreconditionFailed, message)
+}
+
+// ValidationFailed sends a 422 Unprocessable Entity response listing the
+// invalid fields
+func ValidationFailed(w http.ResponseWriter, fields interface{}) {
+	ErrorWithData(w, http.StatusUnprocessableEntity, "Validation failed", fields)
+}
+
 // InternalError sends a 500 Internal Server Error response
 func InternalError(w http.ResponseWriter, message string) {
 	Error(w, http.StatusInternalServerError, message)
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+type AdminHandler struct {
+	adminService services.AdminServiceInterface
+}
+
+func NewAdminHandler(adminService services.AdminServiceInterface) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// ListUsers handles listing and searching every user
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := &models.UserListQuery{
+		Cursor: params.Get("cursor"),
+		Search: params.Get("search"),
+	}
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			api.BadRequest(w, "Invalid limit parameter")
+			return
+		}
+		query.Limit = limit
+	}
+
+	users, page, err := h.adminService.ListUsers(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidListQuery) {
+			api.BadRequest(w, err.Error())
+			return
+		}
+		logger.Error("Failed to list users: %v", err)
+		api.InternalError(w, "Failed to list users")
+		return
+	}
+
+	api.SuccessWithMeta(w, users, map[string]interface{}{"pagination": page})
+}
+
+// DisableUser handles disabling a user's account
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, true)
+}
+
+// EnableUser handles re-enabling a disabled account
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, false)
+}
+
+func (h *AdminHandler) setUserDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	adminID := r.Context().Value("user_id").(uint)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid user ID")
+		return
+	}
+
+	user, err := h.adminService.SetUserDisabled(r.Context(), adminID, uint(id), disabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			api.NotFound(w, "User not found")
+		case errors.Is(err, services.ErrCannotDisableSelf):
+			api.BadRequest(w, "You cannot disable your own account")
+		default:
+			logger.Error("Failed to update user: %v", err)
+			api.InternalError(w, "Failed to update user")
+		}
+		return
+	}
+
+	api.Success(w, user)
+}
+
+// ListURLs handles listing and searching every user's links
+func (h *AdminHandler) ListURLs(w http.ResponseWriter, r *http.Request) {
+	listQuery, param := parseURLListQuery(r)
+	if listQuery == nil {
+		api.BadRequest(w, "Invalid "+param+" parameter")
+		return
+	}
+	query := &models.AdminURLListQuery{URLListQuery: *listQuery}
+	if value := r.URL.Query().Get("owner"); value != "" {
+		owner, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			api.BadRequest(w, "Invalid owner parameter")
+			return
+		}
+		query.Owner = uint(owner)
+	}
+
+	urls, page, err := h.adminService.ListURLs(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidListQuery) {
+			api.BadRequest(w, err.Error())
+			return
+		}
+		logger.Error("Failed to list URLs: %v", err)
+		api.InternalError(w, "Failed to list URLs")
+		return
+	}
+
+	api.SuccessWithMeta(w, urls, map[string]interface{}{"pagination": page})
+}
+
+// DisableURL handles taking a link down; it stops redirecting at once
+func (h *AdminHandler) DisableURL(w http.ResponseWriter, r *http.Request) {
+	var req models.DisableURLRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	h.setURLDisabled(w, r, true, req.Reason)
+}
+
+// EnableURL handles putting a disabled link back up
+func (h *AdminHandler) EnableURL(w http.ResponseWriter, r *http.Request) {
+	h.setURLDisabled(w, r, false, "")
+}
+
+func (h *AdminHandler) setURLDisabled(w http.ResponseWriter, r *http.Request, disabled bool, reason string) {
+	url, err := h.adminService.SetURLDisabled(r.Context(), mux.Vars(r)["shortCode"], disabled, reason)
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			api.NotFound(w, "URL not found")
+			return
+		}
+		logger.Error("Failed to update URL: %v", err)
+		api.InternalError(w, "Failed to update URL")
+		return
+	}
+
+	api.Success(w, url)
+}
+
+// ListBlockedDomains handles listing the destination blocklist
+func (h *AdminHandler) ListBlockedDomains(w http.ResponseWriter, r *http.Request) {
+	domains, err := h.adminService.ListBlockedDomains(r.Context())
+	if err != nil {
+		logger.Error("Failed to list blocked domains: %v", err)
+		api.InternalError(w, "Failed to list blocked domains")
+		return
+	}
+
+	api.Success(w, domains)
+}
+
+// BlockDomain handles adding a domain to the destination blocklist
+func (h *AdminHandler) BlockDomain(w http.ResponseWriter, r *http.Request) {
+	var req models.BlockDomainRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	domain, err := h.adminService.BlockDomain(r.Context(), req.Domain, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDomainAlreadyBlocked):
+			api.Conflict(w, "Domain is already blocked", nil)
+		case errors.Is(err, services.ErrInvalidDestination):
+			api.BadRequest(w, err.Error())
+		default:
+			logger.Error("Failed to block domain: %v", err)
+			api.InternalError(w, "Failed to block domain")
+		}
+		return
+	}
+
+	api.Success(w, domain)
+}
+
+// UnblockDomain handles removing a domain from the destination blocklist
+func (h *AdminHandler) UnblockDomain(w http.ResponseWriter, r *http.Request) {
+	if err := h.adminService.UnblockDomain(r.Context(), mux.Vars(r)["domain"]); err != nil {
+		if errors.Is(err, services.ErrBlockedDomainNotFound) {
+			api.NotFound(w, "Blocked domain not found")
+			return
+		}
+		logger.Error("Failed to unblock domain: %v", err)
+		api.InternalError(w, "Failed to unblock domain")
+		return
+	}
+
+	api.Success(w, nil)
+}
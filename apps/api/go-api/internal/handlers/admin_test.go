@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+type MockAdminService struct {
+	mock.Mock
+}
+
+func (m *MockAdminService) ListUsers(ctx context.Context, query *models.UserListQuery) ([]models.AdminUserResponse, *models.Pagination, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]models.AdminUserResponse), args.Get(1).(*models.Pagination), args.Error(2)
+}
+
+func (m *MockAdminService) SetUserDisabled(ctx context.Context, adminID, userID uint, disabled bool) (*models.AdminUserResponse, error) {
+	args := m.Called(ctx, adminID, userID, disabled)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AdminUserResponse), args.Error(1)
+}
+
+func (m *MockAdminService) ListURLs(ctx context.Context, query *models.AdminURLListQuery) ([]models.AdminURLResponse, *models.Pagination, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]models.AdminURLResponse), args.Get(1).(*models.Pagination), args.Error(2)
+}
+
+func (m *MockAdminService) SetURLDisabled(ctx context.Context, shortCode string, disabled bool, reason string) (*models.AdminURLResponse, error) {
+	args := m.Called(ctx, shortCode, disabled, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AdminURLResponse), args.Error(1)
+}
+
+func (m *MockAdminService) ListBlockedDomains(ctx context.Context) ([]models.BlockedDomain, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BlockedDomain), args.Error(1)
+}
+
+func (m *MockAdminService) BlockDomain(ctx context.Context, domain, reason string) (*models.BlockedDomain, error) {
+	args := m.Called(ctx, domain, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BlockedDomain), args.Error(1)
+}
+
+func (m *MockAdminService) UnblockDomain(ctx context.Context, domain string) error {
+	args := m.Called(ctx, domain)
+	return args.Error(0)
+}
+
+func TestAdminHandler_DisableUser(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockSetup      func(*MockAdminService)
+		expectedStatus int
+	}{
+		{
+			name: "disabled",
+			id:   "2",
+			mockSetup: func(m *MockAdminService) {
+				m.On("SetUserDisabled", mock.Anything, uint(1), uint(2), true).
+					Return(&models.AdminUserResponse{ID: 2, Disabled: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "self",
+			id:   "1",
+			mockSetup: func(m *MockAdminService) {
+				m.On("SetUserDisabled", mock.Anything, uint(1), uint(1), true).
+					Return(nil, services.ErrCannotDisableSelf)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not found",
+			id:   "9",
+			mockSetup: func(m *MockAdminService) {
+				m.On("SetUserDisabled", mock.Anything, uint(1), uint(9), true).
+					Return(nil, services.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid ID",
+			id:             "abc",
+			mockSetup:      func(m *MockAdminService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAdminService)
+			tt.mockSetup(mockService)
+			handler := NewAdminHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/users/"+tt.id+"/disable", nil)
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+
+			handler.DisableUser(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminHandler_ListURLs(t *testing.T) {
+	mockService := new(MockAdminService)
+	mockService.On("ListURLs", mock.Anything, &models.AdminURLListQuery{
+		URLListQuery: models.URLListQuery{Search: "casino"},
+		Owner:        4,
+	}).Return([]models.AdminURLResponse{}, &models.Pagination{Limit: 20}, nil)
+	handler := NewAdminHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/urls?owner=4&search=casino", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListURLs(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/urls?owner=me", nil)
+	w = httptest.NewRecorder()
+
+	handler.ListURLs(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminHandler_DisableURL(t *testing.T) {
+	mockService := new(MockAdminService)
+	mockService.On("SetURLDisabled", mock.Anything, "abc123", true, "phishing").
+		Return(&models.AdminURLResponse{URLResponse: models.URLResponse{ShortCode: "abc123", Disabled: true}}, nil)
+	mockService.On("SetURLDisabled", mock.Anything, "missing", true, "").
+		Return(nil, services.ErrURLNotFound)
+	handler := NewAdminHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/urls/abc123/disable", bytes.NewBufferString(`{"reason":"phishing"}`))
+	req = mux.SetURLVars(req, map[string]string{"shortCode": "abc123"})
+	w := httptest.NewRecorder()
+
+	handler.DisableURL(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"disabled":true`)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/urls/missing/disable", bytes.NewBufferString(`{}`))
+	req = mux.SetURLVars(req, map[string]string{"shortCode": "missing"})
+	w = httptest.NewRecorder()
+
+	handler.DisableURL(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminHandler_BlockDomain(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(*MockAdminService)
+		expectedStatus int
+	}{
+		{
+			name: "blocked",
+			body: `{"domain":"spam.example","reason":"spam"}`,
+			mockSetup: func(m *MockAdminService) {
+				m.On("BlockDomain", mock.Anything, "spam.example", "spam").
+					Return(&models.BlockedDomain{ID: 1, Domain: "spam.example", Reason: "spam"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "already blocked",
+			body: `{"domain":"spam.example"}`,
+			mockSetup: func(m *MockAdminService) {
+				m.On("BlockDomain", mock.Anything, "spam.example", "").
+					Return(nil, services.ErrDomainAlreadyBlocked)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "missing domain",
+			body:           `{"reason":"spam"}`,
+			mockSetup:      func(m *MockAdminService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAdminService)
+			tt.mockSetup(mockService)
+			handler := NewAdminHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/blocked-domains", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.BlockDomain(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+type AnalyticsHandler struct {
+	analyticsService services.AnalyticsServiceInterface
+}
+
+func NewAnalyticsHandler(analyticsService services.AnalyticsServiceInterface) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetURLStats handles getting click statistics for a URL
+func (h *AnalyticsHandler) GetURLStats(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid URL ID")
+		return
+	}
+
+	query, bad := parseStatsQuery(r)
+	if bad != "" {
+		api.BadRequest(w, "Invalid "+bad+" parameter")
+		return
+	}
+
+	stats, err := h.analyticsService.GetURLStats(r.Context(), userID, uint(id), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrURLNotFound):
+			api.NotFound(w, "URL not found")
+		case errors.Is(err, services.ErrInvalidInterval), errors.Is(err, services.ErrInvalidStatsRange):
+			api.BadRequest(w, err.Error())
+		default:
+			logger.Error("Failed to get URL stats: %v", err)
+			api.InternalError(w, "Failed to get URL stats")
+		}
+		return
+	}
+
+	api.Success(w, stats)
+}
+
+// GetTagStats handles getting click statistics rolled up over a tag's links
+func (h *AnalyticsHandler) GetTagStats(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid tag ID")
+		return
+	}
+
+	query, bad := parseStatsQuery(r)
+	if bad != "" {
+		api.BadRequest(w, "Invalid "+bad+" parameter")
+		return
+	}
+
+	stats, err := h.analyticsService.GetTagStats(r.Context(), userID, uint(id), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTagNotFound):
+			api.NotFound(w, "Tag not found")
+		case errors.Is(err, services.ErrInvalidInterval), errors.Is(err, services.ErrInvalidStatsRange):
+			api.BadRequest(w, err.Error())
+		default:
+			logger.Error("Failed to get tag stats: %v", err)
+			api.InternalError(w, "Failed to get tag stats")
+		}
+		return
+	}
+
+	api.Success(w, stats)
+}
+
+// parseStatsQuery reads a stats query from the query string, returning the
+// name of the first invalid parameter
+func parseStatsQuery(r *http.Request) (*models.StatsQuery, string) {
+	query := &models.StatsQuery{Interval: r.URL.Query().Get("interval")}
+	var err error
+	if query.From, err = parseTimeParam(r, "from"); err != nil {
+		return nil, "from"
+	}
+	if query.To, err = parseTimeParam(r, "to"); err != nil {
+		return nil, "to"
+	}
+	return query, ""
+}
+
+// parseTimeParam reads an RFC 3339 timestamp or a YYYY-MM-DD date from the
+// query string, returning the zero time when the parameter is absent
+func parseTimeParam(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
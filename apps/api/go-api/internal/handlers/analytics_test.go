@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+type MockAnalyticsService struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsService) GetURLStats(ctx context.Context, userID uint, urlID uint, query *models.StatsQuery) (*models.URLStats, error) {
+	args := m.Called(ctx, userID, urlID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URLStats), args.Error(1)
+}
+
+func (m *MockAnalyticsService) GetTagStats(ctx context.Context, userID uint, tagID uint, query *models.StatsQuery) (*models.TagStats, error) {
+	args := m.Called(ctx, userID, tagID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TagStats), args.Error(1)
+}
+
+func TestAnalyticsHandler_GetURLStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		urlID          string
+		query          string
+		mockSetup      func(*MockAnalyticsService)
+		expectedStatus int
+		expectedField  string
+		expectedValue  interface{}
+	}{
+		{
+			name:  "success",
+			urlID: "1",
+			query: "?interval=hour&from=2026-01-01&to=2026-01-02T00:00:00Z",
+			mockSetup: func(m *MockAnalyticsService) {
+				m.On("GetURLStats", mock.Anything, uint(1), uint(1), mock.MatchedBy(func(q *models.StatsQuery) bool {
+					return q.Interval == "hour" && q.From.Day() == 1 && q.To.Day() == 2
+				})).Return(&models.URLStats{URLID: 1, ClickStats: models.ClickStats{TotalClicks: 3}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedField:  "status",
+			expectedValue:  "success",
+		},
+		{
+			name:           "invalid from",
+			urlID:          "1",
+			query:          "?from=yesterday",
+			mockSetup:      func(m *MockAnalyticsService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "error",
+			expectedValue:  "Invalid from parameter",
+		},
+		{
+			name:  "invalid interval",
+			urlID: "1",
+			query: "?interval=month",
+			mockSetup: func(m *MockAnalyticsService) {
+				m.On("GetURLStats", mock.Anything, uint(1), uint(1), mock.Anything).
+					Return(nil, services.ErrInvalidInterval)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "error",
+			expectedValue:  services.ErrInvalidInterval.Error(),
+		},
+		{
+			name:  "not owner",
+			urlID: "2",
+			mockSetup: func(m *MockAnalyticsService) {
+				m.On("GetURLStats", mock.Anything, uint(1), uint(2), mock.Anything).
+					Return(nil, services.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedField:  "error",
+			expectedValue:  "URL not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAnalyticsService)
+			tt.mockSetup(mockService)
+			handler := NewAnalyticsHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/urls/"+tt.urlID+"/stats"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			req = mux.SetURLVars(req, map[string]string{"id": tt.urlID})
+			w := httptest.NewRecorder()
+
+			handler.GetURLStats(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var resp map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+			assert.Equal(t, tt.expectedValue, resp[tt.expectedField])
+			mockService.AssertExpectations(t)
+		})
+	}
+}
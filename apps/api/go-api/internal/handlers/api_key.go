@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyServiceInterface
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyServiceInterface) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// ListAPIKeys handles listing the user's API keys
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+
+	keys, err := h.apiKeyService.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to list API keys: %v", err)
+		api.InternalError(w, "Failed to list API keys")
+		return
+	}
+
+	api.Success(w, keys)
+}
+
+// CreateAPIKey handles creating an API key; the key is only returned here
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+
+	var req models.APIKeyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(r.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScope) {
+			api.BadRequest(w, err.Error())
+			return
+		}
+		logger.Error("Failed to create API key: %v", err)
+		api.InternalError(w, "Failed to create API key")
+		return
+	}
+
+	api.Success(w, key)
+}
+
+// RevokeAPIKey handles revoking an API key
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid API key ID")
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(r.Context(), userID, uint(id)); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			api.NotFound(w, "API key not found")
+			return
+		}
+		logger.Error("Failed to revoke API key: %v", err)
+		api.InternalError(w, "Failed to revoke API key")
+		return
+	}
+
+	api.Success(w, nil)
+}
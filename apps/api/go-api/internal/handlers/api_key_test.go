@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) ListAPIKeys(ctx context.Context, userID uint) ([]models.APIKeyResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKeyResponse), args.Error(1)
+}
+
+func (m *MockAPIKeyService) CreateAPIKey(ctx context.Context, userID uint, req *models.APIKeyRequest) (*models.CreatedAPIKeyResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreatedAPIKeyResponse), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(ctx context.Context, userID uint, id uint) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func TestAPIKeyHandler_CreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(*MockAPIKeyService)
+		expectedStatus int
+	}{
+		{
+			name: "created",
+			body: `{"name":"CI","scopes":["urls:read"]}`,
+			mockSetup: func(m *MockAPIKeyService) {
+				m.On("CreateAPIKey", mock.Anything, uint(1), &models.APIKeyRequest{Name: "CI", Scopes: []string{"urls:read"}}).
+					Return(&models.CreatedAPIKeyResponse{Key: "rfk_secret"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "unknown scope",
+			body: `{"name":"CI","scopes":["admin"]}`,
+			mockSetup: func(m *MockAPIKeyService) {
+				m.On("CreateAPIKey", mock.Anything, uint(1), mock.Anything).
+					Return(nil, fmt.Errorf("%w: %q is not a scope", services.ErrInvalidScope, "admin"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing name",
+			body:           `{"scopes":["urls:read"]}`,
+			mockSetup:      func(m *MockAPIKeyService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAPIKeyService)
+			tt.mockSetup(mockService)
+			handler := NewAPIKeyHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/keys", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			w := httptest.NewRecorder()
+
+			handler.CreateAPIKey(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyHandler_RevokeAPIKey(t *testing.T) {
+	mockService := new(MockAPIKeyService)
+	mockService.On("RevokeAPIKey", mock.Anything, uint(1), uint(3)).Return(services.ErrAPIKeyNotFound)
+	handler := NewAPIKeyHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodDelete, "/auth/keys/3", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+	req = mux.SetURLVars(req, map[string]string{"id": "3"})
+	w := httptest.NewRecorder()
+
+	handler.RevokeAPIKey(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
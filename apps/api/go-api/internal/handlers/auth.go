@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
@@ -21,9 +21,7 @@ func NewAuthHandler(authService services.AuthServiceInterface) *AuthHandler {
 // Register handles user registration
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Error("Failed to decode register request: %v", err)
-		api.BadRequest(w, "Invalid request body")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -40,14 +38,16 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 // Login handles user login
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Error("Failed to decode login request: %v", err)
-		api.BadRequest(w, "Invalid request body")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	resp, err := h.authService.Login(r.Context(), &req)
 	if err != nil {
+		if errors.Is(err, services.ErrAccountDisabled) {
+			api.Forbidden(w, "Account is disabled")
+			return
+		}
 		logger.Error("Login error: %v", err)
 		api.Error(w, http.StatusUnauthorized, err.Error())
 		return
@@ -55,3 +55,105 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	api.Success(w, resp)
 }
+
+// Refresh exchanges a refresh token for a new access and refresh token
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	resp, err := h.authService.Refresh(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			api.Unauthorized(w, "Invalid or expired refresh token")
+			return
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			api.Forbidden(w, "Account is disabled")
+			return
+		}
+		logger.Error("Refresh error: %v", err)
+		api.InternalError(w, "Failed to refresh token")
+		return
+	}
+
+	api.Success(w, resp)
+}
+
+// Logout revokes the access token used for the request, and the refresh
+// token given in the body, if any
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("token_claims").(*services.AccessClaims)
+
+	var req models.LogoutRequest
+	if r.ContentLength != 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.authService.Logout(r.Context(), claims, &req); err != nil {
+		if errors.Is(err, services.ErrTokenNotRevocable) {
+			api.BadRequest(w, "This token can only be revoked by logging out everywhere")
+			return
+		}
+		logger.Error("Logout error: %v", err)
+		api.InternalError(w, "Failed to log out")
+		return
+	}
+
+	api.Success(w, nil)
+}
+
+// LogoutEverywhere revokes every token issued to the user
+func (h *AuthHandler) LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+
+	if err := h.authService.LogoutEverywhere(r.Context(), userID); err != nil {
+		logger.Error("Logout everywhere error: %v", err)
+		api.InternalError(w, "Failed to log out")
+		return
+	}
+
+	api.Success(w, nil)
+}
+
+// VerifyEmail confirms a user's email address from the link sent to it
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		api.BadRequest(w, "token is required")
+		return
+	}
+
+	if err := h.authService.VerifyEmail(r.Context(), token); err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			api.BadRequest(w, "Invalid or expired verification link")
+			return
+		}
+		logger.Error("Verify email error: %v", err)
+		api.InternalError(w, "Failed to verify email")
+		return
+	}
+
+	api.Success(w, map[string]bool{"email_verified": true})
+}
+
+// ResendVerification emails the user a new verification link
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+
+	if err := h.authService.ResendVerification(r.Context(), userID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmailAlreadyVerified):
+			api.Conflict(w, "Email is already verified", nil)
+		case errors.Is(err, services.ErrTooManyVerificationMails):
+			api.Error(w, http.StatusTooManyRequests, "Too many verification emails, please try again later")
+		default:
+			logger.Error("Resend verification error: %v", err)
+			api.InternalError(w, "Failed to send verification email")
+		}
+		return
+	}
+
+	api.Success(w, nil)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
 )
 
 type MockAuthService struct {
@@ -35,6 +36,34 @@ func (m *MockAuthService) Login(ctx context.Context, req *models.LoginRequest) (
 	return args.Get(0).(*models.AuthResponse), args.Error(1)
 }
 
+func (m *MockAuthService) Refresh(ctx context.Context, req *models.RefreshRequest) (*models.AuthResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, claims *services.AccessClaims, req *models.LogoutRequest) error {
+	args := m.Called(ctx, claims, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LogoutEverywhere(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResendVerification(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
 func TestAuthHandler_Register(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -71,6 +100,21 @@ func TestAuthHandler_Register(t *testing.T) {
 			expectedField:  "error",
 			expectedValue:  "Invalid request body",
 		},
+		{
+			name: "invalid fields",
+			requestBody: models.RegisterRequest{
+				Name:     "Test User",
+				Email:    "not-an-email",
+				Password: "short",
+			},
+			mockSetup:      func(m *MockAuthService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedField:  "data",
+			expectedValue: []interface{}{
+				map[string]interface{}{"field": "email", "reason": "must be a valid email address"},
+				map[string]interface{}{"field": "password", "reason": "password must be at least 8 characters long"},
+			},
+		},
 		{
 			name: "user exists",
 			requestBody: models.RegisterRequest{
@@ -164,6 +208,20 @@ func TestAuthHandler_Login(t *testing.T) {
 			expectedField:  "error",
 			expectedValue:  "invalid credentials",
 		},
+		{
+			name: "disabled account",
+			requestBody: models.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			mockSetup: func(m *MockAuthService) {
+				m.On("Login", mock.Anything, mock.AnythingOfType("*models.LoginRequest")).
+					Return(nil, services.ErrAccountDisabled)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedField:  "error",
+			expectedValue:  "Account is disabled",
+		},
 	}
 
 	for _, tt := range tests {
@@ -192,3 +250,166 @@ func TestAuthHandler_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(*MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:        "rotated",
+			requestBody: `{"refresh_token":"r1"}`,
+			mockSetup: func(m *MockAuthService) {
+				m.On("Refresh", mock.Anything, &models.RefreshRequest{RefreshToken: "r1"}).
+					Return(&models.AuthResponse{Token: "token", RefreshToken: "r2", ExpiresIn: 900}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "reused",
+			requestBody: `{"refresh_token":"r1"}`,
+			mockSetup: func(m *MockAuthService) {
+				m.On("Refresh", mock.Anything, mock.Anything).Return(nil, services.ErrRefreshTokenReused)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing token",
+			requestBody:    `{}`,
+			mockSetup:      func(m *MockAuthService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAuthService)
+			tt.mockSetup(mockService)
+			handler := NewAuthHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBufferString(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			handler.Refresh(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	claims := &services.AccessClaims{UserID: 1, ID: "abc"}
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(*MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "without body",
+			mockSetup: func(m *MockAuthService) {
+				m.On("Logout", mock.Anything, claims, &models.LogoutRequest{}).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "with refresh token",
+			requestBody: `{"refresh_token":"r1"}`,
+			mockSetup: func(m *MockAuthService) {
+				m.On("Logout", mock.Anything, claims, &models.LogoutRequest{RefreshToken: "r1"}).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "token without ID",
+			mockSetup: func(m *MockAuthService) {
+				m.On("Logout", mock.Anything, claims, mock.Anything).Return(services.ErrTokenNotRevocable)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAuthService)
+			tt.mockSetup(mockService)
+			handler := NewAuthHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/logout", bytes.NewBufferString(tt.requestBody))
+			ctx := context.WithValue(req.Context(), "user_id", uint(1))
+			req = req.WithContext(context.WithValue(ctx, "token_claims", claims))
+			w := httptest.NewRecorder()
+
+			handler.Logout(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(*MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:  "verified",
+			query: "?token=1.2.abc",
+			mockSetup: func(m *MockAuthService) {
+				m.On("VerifyEmail", mock.Anything, "1.2.abc").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "invalid token",
+			query: "?token=1.2.bad",
+			mockSetup: func(m *MockAuthService) {
+				m.On("VerifyEmail", mock.Anything, "1.2.bad").Return(services.ErrInvalidVerificationToken)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing token",
+			mockSetup:      func(m *MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAuthService)
+			tt.mockSetup(mockService)
+			handler := NewAuthHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/verify"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.VerifyEmail(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_ResendVerification(t *testing.T) {
+	mockService := new(MockAuthService)
+	mockService.On("ResendVerification", mock.Anything, uint(1)).Return(services.ErrEmailAlreadyVerified)
+	handler := NewAuthHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/verify/resend", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+	w := httptest.NewRecorder()
+
+	handler.ResendVerification(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockService.AssertExpectations(t)
+}
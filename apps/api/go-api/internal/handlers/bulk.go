@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+// maxBulkBodySize bounds the size of a bulk request body or CSV upload
+const maxBulkBodySize = 10 << 20
+
+// BulkCreateURLs handles creating links from a JSON array of create requests
+// or from a CSV file, sent as the body or as the "file" field of a form
+func (h *URLHandler) BulkCreateURLs(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodySize)
+
+	var reqs []models.CreateURLRequest
+	var rows []int
+	var rejected []models.BulkResult
+	var err error
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		reqs, rows, rejected, err = parseBulkCSV(r.Body)
+	case "multipart/form-data":
+		file, _, formErr := r.FormFile("file")
+		if formErr != nil {
+			api.BadRequest(w, "Missing CSV file")
+			return
+		}
+		defer file.Close()
+		reqs, rows, rejected, err = parseBulkCSV(file)
+	default:
+		err = json.NewDecoder(r.Body).Decode(&reqs)
+	}
+	if err != nil {
+		logger.Error("Failed to decode bulk create request: %v", err)
+		api.BadRequest(w, "Invalid request body: "+err.Error())
+		return
+	}
+
+	results, err := h.urlService.BulkCreateURLs(r.Context(), userID, reqs)
+	if err != nil {
+		writeBulkError(w, err)
+		return
+	}
+
+	// Report CSV rows by their position in the file, next to those that
+	// could not be parsed
+	if rows != nil {
+		for i := range results {
+			results[i].Index = rows[i]
+		}
+		results = append(results, rejected...)
+		sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+	}
+
+	api.Success(w, models.NewBulkResponse(results))
+}
+
+// BulkUpdateURLs handles applying merge patches to several links
+func (h *URLHandler) BulkUpdateURLs(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodySize)
+
+	var items []models.BulkUpdateItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		logger.Error("Failed to decode bulk update request: %v", err)
+		api.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	results, err := h.urlService.BulkUpdateURLs(r.Context(), userID, items)
+	if err != nil {
+		writeBulkError(w, err)
+		return
+	}
+
+	api.Success(w, models.NewBulkResponse(results))
+}
+
+// BulkDeleteURLs handles moving several links to the trash
+func (h *URLHandler) BulkDeleteURLs(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodySize)
+
+	var req models.BulkDeleteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	results, err := h.urlService.BulkDeleteURLs(r.Context(), userID, req.IDs)
+	if err != nil {
+		writeBulkError(w, err)
+		return
+	}
+
+	api.Success(w, models.NewBulkResponse(results))
+}
+
+func writeBulkError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrTooManyBulkItems) {
+		api.BadRequest(w, err.Error())
+		return
+	}
+	logger.Error("Bulk request failed: %v", err)
+	api.InternalError(w, "Bulk request failed")
+}
+
+// parseBulkCSV reads create requests from CSV with a header row naming the
+// columns, which are the JSON fields of a create request. It returns the
+// requests that parsed with the row index of each, and a failed result for
+// every row that did not.
+func parseBulkCSV(r io.Reader) (reqs []models.CreateURLRequest, rows []int, rejected []models.BulkResult, err error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	hasURL := false
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := bulkCSVColumns[name]; !ok {
+			return nil, nil, nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		columns[i] = name
+		hasURL = hasURL || name == "original_url"
+	}
+	if !hasURL {
+		return nil, nil, nil, errors.New("CSV must have an original_url column")
+	}
+
+	rows = []int{}
+	for index := 0; ; index++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if index >= services.MaxBulkItems {
+			return nil, nil, nil, services.ErrTooManyBulkItems
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			rejected = append(rejected, models.BulkResult{Index: index, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		req, err := parseBulkCSVRecord(columns, record)
+		if err != nil {
+			rejected = append(rejected, models.BulkResult{Index: index, Error: err.Error()})
+			continue
+		}
+		reqs = append(reqs, *req)
+		rows = append(rows, index)
+	}
+	return reqs, rows, rejected, nil
+}
+
+// bulkCSVColumns maps each CSV column to the create request field it sets
+var bulkCSVColumns = map[string]func(req *models.CreateURLRequest, value string) error{
+	"original_url": func(req *models.CreateURLRequest, value string) error { req.OriginalURL = value; return nil },
+	"title":        func(req *models.CreateURLRequest, value string) error { req.Title = value; return nil },
+	"short_code":   func(req *models.CreateURLRequest, value string) error { req.ShortCode = value; return nil },
+	"fallback_url": func(req *models.CreateURLRequest, value string) error { req.FallbackURL = value; return nil },
+	"password":     func(req *models.CreateURLRequest, value string) error { req.Password = value; return nil },
+	"expires_at": func(req *models.CreateURLRequest, value string) error {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("expires_at must be an RFC 3339 time")
+		}
+		req.ExpiresAt = &t
+		return nil
+	},
+	"max_clicks": func(req *models.CreateURLRequest, value string) error {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("max_clicks must be a whole number")
+		}
+		req.MaxClicks = &n
+		return nil
+	},
+	"redirect_status": func(req *models.CreateURLRequest, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("redirect_status must be a whole number")
+		}
+		req.RedirectStatus = n
+		return nil
+	},
+}
+
+func parseBulkCSVRecord(columns, record []string) (*models.CreateURLRequest, error) {
+	req := &models.CreateURLRequest{}
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if err := bulkCSVColumns[columns[i]](req, value); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
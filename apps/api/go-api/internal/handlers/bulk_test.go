@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+func TestURLHandler_BulkCreateURLs(t *testing.T) {
+	multipartBody := func(csv string) (string, string) {
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		part, _ := form.CreateFormFile("file", "links.csv")
+		part.Write([]byte(csv))
+		form.Close()
+		return buf.String(), form.FormDataContentType()
+	}
+	uploadBody, uploadType := multipartBody("original_url\nhttps://example.com\n")
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		mockSetup      func(*MockURLService)
+		expectedStatus int
+		expectedResult []models.BulkResult
+	}{
+		{
+			name:        "json array",
+			contentType: "application/json",
+			body:        `[{"original_url":"https://example.com"}]`,
+			mockSetup: func(m *MockURLService) {
+				m.On("BulkCreateURLs", mock.Anything, uint(1), []models.CreateURLRequest{{OriginalURL: "https://example.com"}}).
+					Return([]models.BulkResult{{Index: 0, Success: true, ID: 1}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResult: []models.BulkResult{{Index: 0, Success: true, ID: 1}},
+		},
+		{
+			name:        "csv with a bad row",
+			contentType: "text/csv",
+			body:        "original_url,title,max_clicks\nhttps://a.example,A,\nhttps://b.example,B,lots\nhttps://c.example,C,10\n",
+			mockSetup: func(m *MockURLService) {
+				ten := int64(10)
+				m.On("BulkCreateURLs", mock.Anything, uint(1), []models.CreateURLRequest{
+					{OriginalURL: "https://a.example", Title: "A"},
+					{OriginalURL: "https://c.example", Title: "C", MaxClicks: &ten},
+				}).Return([]models.BulkResult{
+					{Index: 0, Success: true, ID: 1},
+					{Index: 1, Error: "short code is already taken"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResult: []models.BulkResult{
+				{Index: 0, Success: true, ID: 1},
+				{Index: 1, Error: "max_clicks must be a whole number"},
+				{Index: 2, Error: "short code is already taken"},
+			},
+		},
+		{
+			name:        "csv upload",
+			contentType: uploadType,
+			body:        uploadBody,
+			mockSetup: func(m *MockURLService) {
+				m.On("BulkCreateURLs", mock.Anything, uint(1), []models.CreateURLRequest{{OriginalURL: "https://example.com"}}).
+					Return([]models.BulkResult{{Index: 0, Success: true, ID: 1}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResult: []models.BulkResult{{Index: 0, Success: true, ID: 1}},
+		},
+		{
+			name:           "csv with unknown column",
+			contentType:    "text/csv",
+			body:           "original_url,colour\nhttps://example.com,red\n",
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "too many items",
+			contentType: "application/json",
+			body:        `[]`,
+			mockSetup: func(m *MockURLService) {
+				m.On("BulkCreateURLs", mock.Anything, uint(1), mock.Anything).
+					Return(nil, services.ErrTooManyBulkItems)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			tt.mockSetup(mockService)
+			handler := NewURLHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/urls/bulk", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			w := httptest.NewRecorder()
+
+			handler.BulkCreateURLs(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedResult != nil {
+				var resp struct {
+					Data models.BulkResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedResult, resp.Data.Results)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_BulkDeleteURLs(t *testing.T) {
+	mockService := new(MockURLService)
+	mockService.On("BulkDeleteURLs", mock.Anything, uint(1), []uint{4, 5}).
+		Return([]models.BulkResult{{Index: 0, Success: true, ID: 4}, {Index: 1, ID: 5, Error: "url not found"}}, nil)
+	handler := NewURLHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodDelete, "/urls/bulk", strings.NewReader(`{"ids":[4,5]}`))
+	req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+	w := httptest.NewRecorder()
+
+	handler.BulkDeleteURLs(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data models.BulkResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Data.Succeeded)
+	assert.Equal(t, 1, resp.Data.Failed)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		expected   string
+	}{
+		{
+			name:       "headers from untrusted peer are ignored",
+			proxies:    []string{"10.0.0.0/8", " 192.0.2.10 "},
+			remoteAddr: "198.51.100.9:5000",
+			forwarded:  "203.0.113.7",
+			realIP:     "203.0.113.8",
+			expected:   "198.51.100.9",
+		},
+		{
+			name:       "no proxies configured",
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  "203.0.113.7",
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "rightmost untrusted hop wins",
+			proxies:    []string{"10.0.0.0/8", " 192.0.2.10 "},
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  "1.2.3.4, 203.0.113.7, 10.0.0.1",
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "single trusted address",
+			proxies:    []string{"10.0.0.0/8", " 192.0.2.10 "},
+			remoteAddr: "192.0.2.10:5000",
+			realIP:     "203.0.113.8",
+			expected:   "203.0.113.8",
+		},
+		{
+			name:       "garbage stops the walk",
+			proxies:    []string{"10.0.0.0/8", " 192.0.2.10 "},
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  "203.0.113.7, not-an-ip",
+			expected:   "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxies, err := ParseTrustedProxies(tt.proxies)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.expected, proxies.ClientIP(req))
+		})
+	}
+}
+
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+}
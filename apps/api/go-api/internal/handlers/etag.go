@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// setETag sends the version of url as its entity tag
+func setETag(w http.ResponseWriter, url *models.URLResponse) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(url.Version, 10)))
+}
+
+// ifMatchVersion returns the URL version an If-Match header requires, or 0
+// when the header is absent or "*". ok is false when the header names
+// something no URL version can match, such as a weak or foreign tag.
+func ifMatchVersion(r *http.Request) (version int64, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	tag, err := strconv.Unquote(header)
+	if err != nil || !strings.HasPrefix(header, `"`) {
+		return 0, false
+	}
+	version, err = strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
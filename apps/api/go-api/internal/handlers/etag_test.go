@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int64
+		ok      bool
+	}{
+		{header: "", version: 0, ok: true},
+		{header: "*", version: 0, ok: true},
+		{header: `"3"`, version: 3, ok: true},
+		{header: `W/"3"`, ok: false},
+		{header: `"abc"`, ok: false},
+		{header: `"0"`, ok: false},
+		{header: "3", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/urls/1", nil)
+			if tt.header != "" {
+				req.Header.Set("If-Match", tt.header)
+			}
+			version, ok := ifMatchVersion(req)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestURLHandler_PatchURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		ifMatch        string
+		body           string
+		mockSetup      func(*MockURLService)
+		expectedStatus int
+		expectedETag   string
+	}{
+		{
+			name:    "success",
+			ifMatch: `"3"`,
+			body:    `{"title":"New title"}`,
+			mockSetup: func(m *MockURLService) {
+				m.On("PatchURL", mock.Anything, uint(1), uint(7), []byte(`{"title":"New title"}`), int64(3)).
+					Return(&models.URLResponse{ID: 7, Title: "New title", Version: 4}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4"`,
+		},
+		{
+			name:    "version mismatch",
+			ifMatch: `"2"`,
+			body:    `{"title":"New title"}`,
+			mockSetup: func(m *MockURLService) {
+				m.On("PatchURL", mock.Anything, uint(1), uint(7), mock.Anything, int64(2)).
+					Return(nil, services.ErrVersionMismatch)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "weak entity tag",
+			ifMatch:        `W/"3"`,
+			body:           `{"title":"New title"}`,
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "invalid patch",
+			body: `[]`,
+			mockSetup: func(m *MockURLService) {
+				m.On("PatchURL", mock.Anything, uint(1), uint(7), mock.Anything, int64(0)).
+					Return(nil, services.ErrInvalidPatch)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not found",
+			body: `{}`,
+			mockSetup: func(m *MockURLService) {
+				m.On("PatchURL", mock.Anything, uint(1), uint(7), mock.Anything, int64(0)).
+					Return(nil, services.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			tt.mockSetup(mockService)
+			handler := NewURLHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPatch, "/urls/7", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			req = mux.SetURLVars(req, map[string]string{"id": "7"})
+			w := httptest.NewRecorder()
+
+			handler.PatchURL(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+			var resp map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
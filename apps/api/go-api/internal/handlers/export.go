@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+// Export formats accepted by ExportURLs
+const (
+	ExportCSV    = "csv"
+	ExportJSON   = "json"
+	ExportNDJSON = "ndjson"
+)
+
+// exportWriter is a services.URLExportWriter that finishes the document once
+// every row has been written
+type exportWriter interface {
+	services.URLExportWriter
+	Close() error
+}
+
+// ExportURLs handles downloading all of the user's links, and optionally
+// their click events, as CSV, JSON or NDJSON
+func (h *URLHandler) ExportURLs(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	query := r.URL.Query()
+
+	includeClicks := false
+	if value := query.Get("include_clicks"); value != "" {
+		var err error
+		if includeClicks, err = strconv.ParseBool(value); err != nil {
+			api.BadRequest(w, "Invalid include_clicks parameter")
+			return
+		}
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = ExportJSON
+	}
+	out := &countingWriter{w: w}
+	var writer exportWriter
+	var contentType string
+	switch format {
+	case ExportCSV:
+		writer, contentType = newCSVExportWriter(out, includeClicks), "text/csv; charset=utf-8"
+	case ExportJSON:
+		writer, contentType = &jsonExportWriter{w: out, includeClicks: includeClicks}, "application/json"
+	case ExportNDJSON:
+		writer, contentType = &jsonExportWriter{w: out, includeClicks: includeClicks, lines: true}, "application/x-ndjson"
+	default:
+		api.BadRequest(w, "Invalid format parameter")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="refurl-links.%s"`, format))
+
+	if err := h.urlService.ExportURLs(r.Context(), userID, includeClicks, writer); err != nil {
+		logger.Error("Failed to export URLs: %v", err)
+		// Once rows have been sent the status can no longer change; the
+		// document is left unterminated so the client sees it is incomplete
+		if out.n == 0 {
+			api.InternalError(w, "Failed to export URLs")
+		}
+		return
+	}
+	if err := writer.Close(); err != nil {
+		logger.Error("Failed to finish URL export: %v", err)
+	}
+}
+
+// countingWriter records whether any of the response has been written
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// jsonExportWriter writes links as a JSON array, or one per line when lines
+// is set. Click events are streamed into a "click_events" array on each link,
+// next to its "clicks" count.
+type jsonExportWriter struct {
+	w             io.Writer
+	includeClicks bool
+	lines         bool
+	urls          int
+	clicks        int
+}
+
+func (j *jsonExportWriter) WriteURL(url *models.URLResponse) error {
+	if err := j.endURL(); err != nil {
+		return err
+	}
+	prefix := "["
+	if j.lines {
+		prefix = ""
+	} else if j.urls > 0 {
+		prefix = ","
+	}
+
+	data, err := json.Marshal(url)
+	if err != nil {
+		return err
+	}
+	if j.includeClicks {
+		// Reopen the object so its clicks can follow without buffering them
+		data = append(data[:len(data)-1], `,"click_events":[`...)
+	}
+	j.urls++
+	j.clicks = 0
+	_, err = io.WriteString(j.w, prefix+string(data))
+	return err
+}
+
+func (j *jsonExportWriter) WriteClick(click *models.ClickEvent) error {
+	data, err := json.Marshal(click)
+	if err != nil {
+		return err
+	}
+	if j.clicks > 0 {
+		data = append([]byte{','}, data...)
+	}
+	j.clicks++
+	_, err = j.w.Write(data)
+	return err
+}
+
+// endURL closes the last link written, if any
+func (j *jsonExportWriter) endURL() error {
+	if j.urls == 0 {
+		return nil
+	}
+	suffix := ""
+	if j.includeClicks {
+		suffix = "]}"
+	}
+	if j.lines {
+		suffix += "\n"
+	}
+	_, err := io.WriteString(j.w, suffix)
+	return err
+}
+
+func (j *jsonExportWriter) Close() error {
+	if err := j.endURL(); err != nil {
+		return err
+	}
+	if j.lines {
+		return nil
+	}
+	suffix := "]\n"
+	if j.urls == 0 {
+		suffix = "[]\n"
+	}
+	_, err := io.WriteString(j.w, suffix)
+	return err
+}
+
+var (
+	csvExportURLColumns = []string{
+		"id", "short_code", "original_url", "title", "clicks", "created_at", "clicks_at",
+		"expires_at", "max_clicks", "fallback_url", "expired", "password_protected", "redirect_status",
+	}
+	csvExportClickColumns = []string{
+		"click_id", "clicked_at", "referrer", "user_agent", "ip_address", "accept_language",
+	}
+)
+
+// csvExportWriter writes one row per link, or with clicks included one row
+// per click event repeating its link's columns. Links without clicks still
+// get a row, with the click columns left empty.
+type csvExportWriter struct {
+	w             *csv.Writer
+	includeClicks bool
+	header        bool
+	// url holds the columns of the last link while its clicks are written
+	url     []string
+	clicked bool
+}
+
+func newCSVExportWriter(w io.Writer, includeClicks bool) *csvExportWriter {
+	return &csvExportWriter{w: csv.NewWriter(w), includeClicks: includeClicks}
+}
+
+func (c *csvExportWriter) WriteURL(url *models.URLResponse) error {
+	if err := c.endURL(); err != nil {
+		return err
+	}
+
+	record := []string{
+		strconv.FormatUint(uint64(url.ID), 10),
+		url.ShortCode,
+		url.OriginalURL,
+		url.Title,
+		strconv.FormatInt(url.Clicks, 10),
+		formatCSVTime(&url.CreatedAt),
+		formatCSVTime(&url.ClicksAt),
+		formatCSVTime(url.ExpiresAt),
+		"",
+		url.FallbackURL,
+		strconv.FormatBool(url.Expired),
+		strconv.FormatBool(url.PasswordProtected),
+		strconv.Itoa(url.RedirectStatus),
+	}
+	if url.MaxClicks != nil {
+		record[8] = strconv.FormatInt(*url.MaxClicks, 10)
+	}
+
+	if !c.includeClicks {
+		return c.w.Write(record)
+	}
+	c.url, c.clicked = record, false
+	return nil
+}
+
+func (c *csvExportWriter) WriteClick(click *models.ClickEvent) error {
+	c.clicked = true
+	record := append(append([]string{}, c.url...),
+		strconv.FormatUint(uint64(click.ID), 10),
+		formatCSVTime(&click.CreatedAt),
+		click.Referrer,
+		click.UserAgent,
+		click.IPAddress,
+		click.AcceptLanguage,
+	)
+	return c.w.Write(record)
+}
+
+func (c *csvExportWriter) Close() error {
+	if err := c.endURL(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// endURL writes the header before the first row, and a row for the last
+// link written if it had no clicks
+func (c *csvExportWriter) endURL() error {
+	if !c.header {
+		c.header = true
+		header := csvExportURLColumns
+		if c.includeClicks {
+			header = append(append([]string{}, header...), csvExportClickColumns...)
+		}
+		if err := c.w.Write(header); err != nil {
+			return err
+		}
+	}
+	if c.url == nil || c.clicked {
+		return nil
+	}
+	return c.w.Write(append(c.url, make([]string, len(csvExportClickColumns))...))
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+func TestURLHandler_ExportURLs(t *testing.T) {
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	// export writes two links, the first with two clicks
+	export := func(args mock.Arguments) {
+		w := args.Get(3).(services.URLExportWriter)
+		w.WriteURL(&models.URLResponse{ID: 1, ShortCode: "one", OriginalURL: "https://one.example", CreatedAt: created})
+		if args.Bool(2) {
+			w.WriteClick(&models.ClickEvent{ID: 10, URLID: 1, Referrer: "a", CreatedAt: created})
+			w.WriteClick(&models.ClickEvent{ID: 11, URLID: 1, Referrer: "b", CreatedAt: created})
+		}
+		w.WriteURL(&models.URLResponse{ID: 2, ShortCode: "two", OriginalURL: "https://two.example", CreatedAt: created})
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		exportErr      error
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{
+			name:           "json",
+			query:          "format=json",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expectedBody:   `[{"id":1,`,
+		},
+		{
+			name:           "json with clicks",
+			query:          "format=json&include_clicks=true",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expectedBody:   `"disabled":false,"click_events":[{"id":10,`,
+		},
+		{
+			name:           "ndjson with clicks",
+			query:          "format=ndjson&include_clicks=1",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/x-ndjson",
+			expectedBody:   `"accept_language":"","created_at":"2026-10-01T12:00:00Z"}]}` + "\n" + `{"id":2,`,
+		},
+		{
+			name:           "csv",
+			query:          "format=csv",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/csv; charset=utf-8",
+			expectedBody:   "status\n1,one,https://one.example,,0,2026-10-01T12:00:00Z,,,,,false,false,0\n2,two,",
+		},
+		{
+			name:           "csv with clicks",
+			query:          "format=csv&include_clicks=true",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/csv; charset=utf-8",
+			expectedBody:   "false,false,0,11,2026-10-01T12:00:00Z,b,,,\n2,two,https://two.example,,0,2026-10-01T12:00:00Z,,,,,false,false,0,,,,,,\n",
+		},
+		{
+			name:           "unknown format",
+			query:          "format=xml",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid include_clicks",
+			query:          "include_clicks=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "query fails before any rows",
+			query:          "format=csv",
+			exportErr:      errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			if tt.expectedStatus == http.StatusOK {
+				mockService.On("ExportURLs", mock.Anything, uint(1), mock.Anything, mock.Anything).
+					Run(export).Return(nil)
+			} else if tt.exportErr != nil {
+				mockService.On("ExportURLs", mock.Anything, uint(1), false, mock.Anything).
+					Return(tt.exportErr)
+			}
+			handler := NewURLHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/urls/export?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			w := httptest.NewRecorder()
+
+			handler.ExportURLs(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedType != "" {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			}
+			assert.True(t, strings.Contains(w.Body.String(), tt.expectedBody), w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_ExportURLs_ClickEventsKeepCount(t *testing.T) {
+	mockService := new(MockURLService)
+	mockService.On("ExportURLs", mock.Anything, uint(1), true, mock.Anything).
+		Run(func(args mock.Arguments) {
+			w := args.Get(3).(services.URLExportWriter)
+			w.WriteURL(&models.URLResponse{ID: 1, ShortCode: "one", Clicks: 5})
+			w.WriteClick(&models.ClickEvent{ID: 10, URLID: 1})
+		}).Return(nil)
+	handler := NewURLHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/urls/export?format=json&include_clicks=true", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+	w := httptest.NewRecorder()
+
+	handler.ExportURLs(w, req)
+
+	// A duplicate key would make the click events overwrite the count
+	var links []struct {
+		Clicks      int64               `json:"clicks"`
+		ClickEvents []models.ClickEvent `json:"click_events"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &links), w.Body.String())
+	require.Len(t, links, 1)
+	assert.Equal(t, int64(5), links[0].Clicks)
+	assert.Len(t, links[0].ClickEvents, 1)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+type FolderHandler struct {
+	folderService services.FolderServiceInterface
+}
+
+func NewFolderHandler(folderService services.FolderServiceInterface) *FolderHandler {
+	return &FolderHandler{
+		folderService: folderService,
+	}
+}
+
+// ListFolders handles listing all of the user's folders
+func (h *FolderHandler) ListFolders(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+
+	folders, err := h.folderService.ListFolders(r.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to list folders: %v", err)
+		api.InternalError(w, "Failed to list folders")
+		return
+	}
+
+	api.Success(w, folders)
+}
+
+// CreateFolder handles creating a folder
+func (h *FolderHandler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+
+	var req models.FolderRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	folder, err := h.folderService.CreateFolder(r.Context(), userID, &req)
+	if err != nil {
+		writeFolderError(w, err, "Failed to create folder")
+		return
+	}
+
+	api.Success(w, folder)
+}
+
+// UpdateFolder handles renaming and moving a folder
+func (h *FolderHandler) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid folder ID")
+		return
+	}
+
+	var req models.FolderRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	folder, err := h.folderService.UpdateFolder(r.Context(), userID, uint(id), &req)
+	if err != nil {
+		writeFolderError(w, err, "Failed to update folder")
+		return
+	}
+
+	api.Success(w, folder)
+}
+
+// DeleteFolder handles deleting a folder; its contents move to its parent
+func (h *FolderHandler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid folder ID")
+		return
+	}
+
+	if err := h.folderService.DeleteFolder(r.Context(), userID, uint(id)); err != nil {
+		writeFolderError(w, err, "Failed to delete folder")
+		return
+	}
+
+	api.Success(w, nil)
+}
+
+// MoveURL handles moving a link into a folder, or out of all folders
+func (h *FolderHandler) MoveURL(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid URL ID")
+		return
+	}
+
+	var req models.URLFolderRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.folderService.MoveURL(r.Context(), userID, uint(id), req.FolderID); err != nil {
+		writeFolderError(w, err, "Failed to move URL")
+		return
+	}
+
+	api.Success(w, req)
+}
+
+func writeFolderError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrFolderNotFound):
+		api.NotFound(w, "Folder not found")
+	case errors.Is(err, services.ErrURLNotFound):
+		api.NotFound(w, "URL not found")
+	case errors.Is(err, services.ErrInvalidFolderParent):
+		api.BadRequest(w, err.Error())
+	default:
+		logger.Error("%s: %v", message, err)
+		api.InternalError(w, message)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+type MockFolderService struct {
+	mock.Mock
+}
+
+func (m *MockFolderService) ListFolders(ctx context.Context, userID uint) ([]models.FolderResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.FolderResponse), args.Error(1)
+}
+
+func (m *MockFolderService) CreateFolder(ctx context.Context, userID uint, req *models.FolderRequest) (*models.FolderResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FolderResponse), args.Error(1)
+}
+
+func (m *MockFolderService) UpdateFolder(ctx context.Context, userID uint, id uint, req *models.FolderRequest) (*models.FolderResponse, error) {
+	args := m.Called(ctx, userID, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FolderResponse), args.Error(1)
+}
+
+func (m *MockFolderService) DeleteFolder(ctx context.Context, userID uint, id uint) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockFolderService) MoveURL(ctx context.Context, userID uint, urlID uint, folderID *uint) error {
+	args := m.Called(ctx, userID, urlID, folderID)
+	return args.Error(0)
+}
+
+func TestFolderHandler_UpdateFolder(t *testing.T) {
+	parent := uint(3)
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(*MockFolderService)
+		expectedStatus int
+	}{
+		{
+			name: "moved",
+			body: `{"name":"Spring","parent_id":3}`,
+			mockSetup: func(m *MockFolderService) {
+				m.On("UpdateFolder", mock.Anything, uint(1), uint(2), &models.FolderRequest{Name: "Spring", ParentID: &parent}).
+					Return(&models.FolderResponse{ID: 2, Name: "Spring", ParentID: &parent}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "into its own subfolder",
+			body: `{"name":"Spring","parent_id":3}`,
+			mockSetup: func(m *MockFolderService) {
+				m.On("UpdateFolder", mock.Anything, uint(1), uint(2), mock.Anything).
+					Return(nil, fmt.Errorf("%w: a folder cannot be moved inside itself", services.ErrInvalidFolderParent))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not found",
+			body: `{"name":"Spring"}`,
+			mockSetup: func(m *MockFolderService) {
+				m.On("UpdateFolder", mock.Anything, uint(1), uint(2), mock.Anything).
+					Return(nil, services.ErrFolderNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFolderService)
+			tt.mockSetup(mockService)
+			handler := NewFolderHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPut, "/folders/2", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			req = mux.SetURLVars(req, map[string]string{"id": "2"})
+			w := httptest.NewRecorder()
+
+			handler.UpdateFolder(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFolderHandler_MoveURL(t *testing.T) {
+	mockService := new(MockFolderService)
+	mockService.On("MoveURL", mock.Anything, uint(1), uint(7), (*uint)(nil)).Return(nil)
+	handler := NewFolderHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodPut, "/urls/7/folder", bytes.NewBufferString(`{"folder_id":null}`))
+	req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+	req = mux.SetURLVars(req, map[string]string{"id": "7"})
+	w := httptest.NewRecorder()
+
+	handler.MoveURL(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
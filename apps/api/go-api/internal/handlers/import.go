@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+// maxImportBodySize bounds the size of an uploaded export
+const maxImportBodySize = 32 << 20
+
+// ImportURLs handles importing links from another shortener's export, sent
+// as the body or as the "file" field of a form. It is a dry run unless
+// commit=true is given.
+func (h *URLHandler) ImportURLs(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	query := r.URL.Query()
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodySize)
+
+	commit := false
+	if value := query.Get("commit"); value != "" {
+		var err error
+		if commit, err = strconv.ParseBool(value); err != nil {
+			api.BadRequest(w, "Invalid commit parameter")
+			return
+		}
+	}
+
+	var body io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			api.BadRequest(w, "Missing import file")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	records, err := services.ParseImport(query.Get("format"), body)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownImportFormat) || errors.Is(err, services.ErrInvalidImport) {
+			api.BadRequest(w, err.Error())
+			return
+		}
+		logger.Error("Failed to read import: %v", err)
+		api.BadRequest(w, "Failed to read import file")
+		return
+	}
+
+	report, err := h.urlService.ImportURLs(r.Context(), userID, records, !commit)
+	if err != nil {
+		logger.Error("Failed to import URLs: %v", err)
+		api.InternalError(w, "Failed to import URLs")
+		return
+	}
+
+	api.Success(w, report)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestURLHandler_ImportURLs(t *testing.T) {
+	records := []models.ImportRecord{{Row: 2, ShortCode: "abc123", OriginalURL: "https://example.com"}}
+
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		mockSetup      func(*MockURLService)
+		expectedStatus int
+	}{
+		{
+			name:  "dry run by default",
+			query: "format=csv",
+			body:  "short_code,original_url\nabc123,https://example.com\n",
+			mockSetup: func(m *MockURLService) {
+				m.On("ImportURLs", mock.Anything, uint(1), records, true).
+					Return(&models.ImportReport{DryRun: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "commit",
+			query: "format=csv&commit=true",
+			body:  "short_code,original_url\nabc123,https://example.com\n",
+			mockSetup: func(m *MockURLService) {
+				m.On("ImportURLs", mock.Anything, uint(1), records, false).
+					Return(&models.ImportReport{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown format",
+			query:          "format=tinyurl",
+			body:           "",
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed dump",
+			query:          "format=yourls-json",
+			body:           "{",
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid commit",
+			query:          "format=csv&commit=soon",
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			tt.mockSetup(mockService)
+			handler := NewURLHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/urls/import?"+tt.query, strings.NewReader(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			w := httptest.NewRecorder()
+
+			handler.ImportURLs(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/validator"
+)
+
+// decodeRequest decodes the JSON body of r into dst and checks it against its
+// validate tags. On failure it writes a 400 for a malformed body or a 422
+// listing the invalid fields, and returns false.
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		logger.Error("Failed to decode request body: %v", err)
+		api.BadRequest(w, "Invalid request body")
+		return false
+	}
+
+	if err := validator.Struct(dst); err != nil {
+		var fields validator.ValidationErrors
+		if errors.As(err, &fields) {
+			api.ValidationFailed(w, fields)
+			return false
+		}
+		logger.Error("Failed to validate request body: %v", err)
+		api.InternalError(w, "Failed to validate request")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+type TagHandler struct {
+	tagService services.TagServiceInterface
+}
+
+func NewTagHandler(tagService services.TagServiceInterface) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+	}
+}
+
+// ListTags handles listing the user's tags
+func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+
+	tags, err := h.tagService.ListTags(r.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to list tags: %v", err)
+		api.InternalError(w, "Failed to list tags")
+		return
+	}
+
+	api.Success(w, tags)
+}
+
+// CreateTag handles creating a tag
+func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+
+	var req models.TagRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	tag, err := h.tagService.CreateTag(r.Context(), userID, &req)
+	if err != nil {
+		writeTagError(w, err, "Failed to create tag")
+		return
+	}
+
+	api.Success(w, tag)
+}
+
+// UpdateTag handles renaming a tag
+func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid tag ID")
+		return
+	}
+
+	var req models.TagRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	tag, err := h.tagService.UpdateTag(r.Context(), userID, uint(id), &req)
+	if err != nil {
+		writeTagError(w, err, "Failed to update tag")
+		return
+	}
+
+	api.Success(w, tag)
+}
+
+// DeleteTag handles deleting a tag, which removes it from its links
+func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid tag ID")
+		return
+	}
+
+	if err := h.tagService.DeleteTag(r.Context(), userID, uint(id)); err != nil {
+		writeTagError(w, err, "Failed to delete tag")
+		return
+	}
+
+	api.Success(w, nil)
+}
+
+// SetURLTags handles replacing the tags of a link
+func (h *TagHandler) SetURLTags(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid URL ID")
+		return
+	}
+
+	var req models.URLTagsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	tags, err := h.tagService.SetURLTags(r.Context(), userID, uint(id), req.Tags)
+	if err != nil {
+		writeTagError(w, err, "Failed to set URL tags")
+		return
+	}
+
+	api.Success(w, models.URLTagsRequest{Tags: tags})
+}
+
+func writeTagError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		api.NotFound(w, "Tag not found")
+	case errors.Is(err, services.ErrURLNotFound):
+		api.NotFound(w, "URL not found")
+	case errors.Is(err, services.ErrTagExists):
+		api.Conflict(w, err.Error(), nil)
+	case errors.Is(err, services.ErrInvalidTag):
+		api.BadRequest(w, err.Error())
+	default:
+		logger.Error("%s: %v", message, err)
+		api.InternalError(w, message)
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) ListTags(ctx context.Context, userID uint) ([]models.TagResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TagResponse), args.Error(1)
+}
+
+func (m *MockTagService) CreateTag(ctx context.Context, userID uint, req *models.TagRequest) (*models.TagResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TagResponse), args.Error(1)
+}
+
+func (m *MockTagService) UpdateTag(ctx context.Context, userID uint, id uint, req *models.TagRequest) (*models.TagResponse, error) {
+	args := m.Called(ctx, userID, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TagResponse), args.Error(1)
+}
+
+func (m *MockTagService) DeleteTag(ctx context.Context, userID uint, id uint) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockTagService) SetURLTags(ctx context.Context, userID uint, urlID uint, names []string) ([]string, error) {
+	args := m.Called(ctx, userID, urlID, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func TestTagHandler_CreateTag(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(*MockTagService)
+		expectedStatus int
+	}{
+		{
+			name: "created",
+			body: `{"name":"spring"}`,
+			mockSetup: func(m *MockTagService) {
+				m.On("CreateTag", mock.Anything, uint(1), &models.TagRequest{Name: "spring"}).
+					Return(&models.TagResponse{ID: 1, Name: "spring"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "duplicate",
+			body: `{"name":"spring"}`,
+			mockSetup: func(m *MockTagService) {
+				m.On("CreateTag", mock.Anything, uint(1), mock.Anything).Return(nil, services.ErrTagExists)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "missing name",
+			body:           `{}`,
+			mockSetup:      func(m *MockTagService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockTagService)
+			tt.mockSetup(mockService)
+			handler := NewTagHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/tags", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			w := httptest.NewRecorder()
+
+			handler.CreateTag(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTagHandler_SetURLTags(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(*MockTagService)
+		expectedStatus int
+	}{
+		{
+			name: "replaced",
+			body: `{"tags":["Spring","launch"]}`,
+			mockSetup: func(m *MockTagService) {
+				m.On("SetURLTags", mock.Anything, uint(1), uint(7), []string{"Spring", "launch"}).
+					Return([]string{"launch", "spring"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid tag",
+			body: `{"tags":[""]}`,
+			mockSetup: func(m *MockTagService) {
+				m.On("SetURLTags", mock.Anything, uint(1), uint(7), []string{""}).
+					Return(nil, services.ErrInvalidTag)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "url not found",
+			body: `{"tags":[]}`,
+			mockSetup: func(m *MockTagService) {
+				m.On("SetURLTags", mock.Anything, uint(1), uint(7), []string{}).
+					Return(nil, services.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockTagService)
+			tt.mockSetup(mockService)
+			handler := NewTagHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPut, "/urls/7/tags", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			req = mux.SetURLVars(req, map[string]string{"id": "7"})
+			w := httptest.NewRecorder()
+
+			handler.SetURLTags(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+var unlockTemplate = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Protected link</title>
+</head>
+<body>
+<main>
+<h1>This link is password protected</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post">
+<label for="password">Password</label>
+<input id="password" name="password" type="password" autocomplete="off" required autofocus>
+<button type="submit">Continue</button>
+</form>
+</main>
+</body>
+</html>
+`))
+
+// unlockCookieName is scoped per link so unlocking one does not unlock others
+func unlockCookieName(urlID uint) string {
+	return "refurl_unlock_" + strconv.FormatUint(uint64(urlID), 10)
+}
+
+// renderUnlockForm serves the password prompt for a protected link
+func renderUnlockForm(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := unlockTemplate.Execute(w, struct{ Error string }{message}); err != nil {
+		logger.Error("Failed to render unlock form: %v", err)
+	}
+}
+
+// UnlockURL handles the password form of a protected link. On success it
+// sets a short-lived unlock cookie and sends the visitor back to the link.
+func (h *URLHandler) UnlockURL(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	shortCode := vars["shortCode"]
+
+	if err := r.ParseForm(); err != nil {
+		renderUnlockForm(w, http.StatusBadRequest, "Invalid form submission")
+		return
+	}
+
+	url, token, err := h.urlService.UnlockURL(r.Context(), shortCode, r.PostFormValue("password"), h.trustedProxies.ClientIP(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrURLNotFound):
+			api.NotFound(w, "URL not found")
+		case errors.Is(err, services.ErrTooManyAttempts):
+			renderUnlockForm(w, http.StatusTooManyRequests, "Too many attempts, please try again later")
+		case errors.Is(err, services.ErrInvalidLinkPassword):
+			renderUnlockForm(w, http.StatusUnauthorized, "Incorrect password")
+		default:
+			logger.Error("Failed to unlock URL: %v", err)
+			api.InternalError(w, "Failed to unlock URL")
+		}
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     unlockCookieName(url.ID),
+		Value:    token,
+		Path:     r.URL.Path,
+		Expires:  time.Now().Add(h.urlService.UnlockTTL()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+func TestURLHandler_RedirectToOriginal_Protected(t *testing.T) {
+	protected := &models.URLResponse{ID: 7, OriginalURL: "https://example.com/docs", ShortCode: "docs", PasswordProtected: true}
+
+	tests := []struct {
+		name             string
+		cookie           *http.Cookie
+		mockSetup        func(*MockURLService)
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name: "no cookie shows the form",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "docs").Return(protected, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "invalid cookie shows the form",
+			cookie: &http.Cookie{Name: unlockCookieName(7), Value: "forged"},
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "docs").Return(protected, nil)
+				m.On("VerifyUnlockToken", protected, "forged").Return(false)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "valid cookie redirects",
+			cookie: &http.Cookie{Name: unlockCookieName(7), Value: "token"},
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "docs").Return(protected, nil)
+				m.On("VerifyUnlockToken", protected, "token").Return(true)
+				m.On("RecordClick", mock.Anything, mock.Anything).Return(nil)
+				m.On("RedirectStatus", protected).Return(http.StatusFound)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com/docs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			tt.mockSetup(mockService)
+			handler := NewURLHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/urls/go/docs", nil)
+			req = mux.SetURLVars(req, map[string]string{"shortCode": "docs"})
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			w := httptest.NewRecorder()
+
+			handler.RedirectToOriginal(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `<form method="post">`)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_UnlockURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockSetup      func(*MockURLService)
+		expectedStatus int
+		expectCookie   bool
+	}{
+		{
+			name: "correct password",
+			mockSetup: func(m *MockURLService) {
+				m.On("UnlockURL", mock.Anything, "docs", "secret", "192.0.2.1").
+					Return(&models.URLResponse{ID: 7, ShortCode: "docs"}, "token", nil)
+			},
+			expectedStatus: http.StatusSeeOther,
+			expectCookie:   true,
+		},
+		{
+			name: "wrong password",
+			mockSetup: func(m *MockURLService) {
+				m.On("UnlockURL", mock.Anything, "docs", "secret", "192.0.2.1").
+					Return(nil, "", services.ErrInvalidLinkPassword)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "rate limited",
+			mockSetup: func(m *MockURLService) {
+				m.On("UnlockURL", mock.Anything, "docs", "secret", "192.0.2.1").
+					Return(nil, "", services.ErrTooManyAttempts)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			tt.mockSetup(mockService)
+			handler := NewURLHandler(mockService)
+
+			form := url.Values{"password": {"secret"}}
+			req := httptest.NewRequest(http.MethodPost, "/api/urls/go/docs", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req = mux.SetURLVars(req, map[string]string{"shortCode": "docs"})
+			w := httptest.NewRecorder()
+
+			handler.UnlockURL(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			cookies := w.Result().Cookies()
+			if tt.expectCookie {
+				require.Len(t, cookies, 1)
+				assert.Equal(t, unlockCookieName(7), cookies[0].Name)
+				assert.Equal(t, "token", cookies[0].Value)
+				assert.Equal(t, "/api/urls/go/docs", cookies[0].Path)
+				assert.True(t, cookies[0].HttpOnly)
+				assert.Equal(t, "/api/urls/go/docs", w.Header().Get("Location"))
+			} else {
+				assert.Empty(t, cookies)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
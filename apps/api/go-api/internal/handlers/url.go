@@ -1,25 +1,48 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
 	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/validator"
 )
 
+// permanentRedirectMaxAge bounds how long browsers may cache a permanent
+// redirect, so destination changes eventually reach every visitor
+const permanentRedirectMaxAge = 24 * time.Hour
+
 type URLHandler struct {
-	urlService services.URLServiceInterface
+	urlService     services.URLServiceInterface
+	trustedProxies TrustedProxies
+}
+
+// URLHandlerOption configures a URLHandler
+type URLHandlerOption func(*URLHandler)
+
+// WithTrustedProxies sets the reverse proxies whose forwarding headers are
+// used for the visitor address
+func WithTrustedProxies(proxies TrustedProxies) URLHandlerOption {
+	return func(h *URLHandler) {
+		h.trustedProxies = proxies
+	}
 }
 
-func NewURLHandler(urlService services.URLServiceInterface) *URLHandler {
-	return &URLHandler{
+func NewURLHandler(urlService services.URLServiceInterface, opts ...URLHandlerOption) *URLHandler {
+	h := &URLHandler{
 		urlService: urlService,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // CreateURL handles URL creation
@@ -27,14 +50,15 @@ func (h *URLHandler) CreateURL(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(uint)
 
 	var req models.CreateURLRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Error("Failed to decode request body: %v", err)
-		api.BadRequest(w, "Invalid request body")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	url, err := h.urlService.CreateURL(r.Context(), userID, &req)
 	if err != nil {
+		if writeURLError(w, err) {
+			return
+		}
 		logger.Error("Failed to create URL: %v", err)
 		api.InternalError(w, "Failed to create URL")
 		return
@@ -64,6 +88,7 @@ func (h *URLHandler) GetURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, url)
 	api.Success(w, url)
 }
 
@@ -71,14 +96,66 @@ func (h *URLHandler) GetURL(w http.ResponseWriter, r *http.Request) {
 func (h *URLHandler) GetUserURLs(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(uint)
 
-	urls, err := h.urlService.GetUserURLs(r.Context(), userID)
+	query, param := parseURLListQuery(r)
+	if query == nil {
+		api.BadRequest(w, "Invalid "+param+" parameter")
+		return
+	}
+
+	urls, page, err := h.urlService.GetUserURLs(r.Context(), userID, query)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidListQuery) {
+			api.BadRequest(w, err.Error())
+			return
+		}
 		logger.Error("Failed to get user URLs: %v", err)
 		api.InternalError(w, "Failed to get user URLs")
 		return
 	}
 
-	api.Success(w, urls)
+	api.SuccessWithMeta(w, urls, map[string]interface{}{"pagination": page})
+}
+
+// parseURLListQuery reads the paging, sorting and filter parameters of a
+// link listing. When one does not parse it returns nil and the parameter name.
+func parseURLListQuery(r *http.Request) (*models.URLListQuery, string) {
+	params := r.URL.Query()
+	query := &models.URLListQuery{
+		Cursor: params.Get("cursor"),
+		Sort:   params.Get("sort"),
+		Order:  params.Get("order"),
+		Search: params.Get("search"),
+		Tag:    params.Get("tag"),
+	}
+
+	var err error
+	if value := params.Get("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil {
+			return nil, "limit"
+		}
+	}
+	if value := params.Get("min_clicks"); value != "" {
+		minClicks, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, "min_clicks"
+		}
+		query.MinClicks = &minClicks
+	}
+	if value := params.Get("folder"); value != "" {
+		folderID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, "folder"
+		}
+		id := uint(folderID)
+		query.FolderID = &id
+	}
+	if query.CreatedFrom, err = parseTimeParam(r, "created_from"); err != nil {
+		return nil, "created_from"
+	}
+	if query.CreatedTo, err = parseTimeParam(r, "created_to"); err != nil {
+		return nil, "created_to"
+	}
+	return query, ""
 }
 
 // UpdateURL handles updating a URL
@@ -91,24 +168,73 @@ func (h *URLHandler) UpdateURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		api.PreconditionFailed(w, services.ErrVersionMismatch.Error())
+		return
+	}
+
 	var req models.UpdateURLRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Error("Failed to decode request body: %v", err)
-		api.BadRequest(w, "Invalid request body")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	url, err := h.urlService.UpdateURL(r.Context(), userID, uint(id), &req)
+	url, err := h.urlService.UpdateURL(r.Context(), userID, uint(id), &req, version)
 	if err != nil {
 		if err.Error() == "url not found" {
 			api.NotFound(w, "URL not found")
 			return
 		}
+		if writeURLError(w, err) {
+			return
+		}
 		logger.Error("Failed to update URL: %v", err)
 		api.InternalError(w, "Failed to update URL")
 		return
 	}
 
+	setETag(w, url)
+	api.Success(w, url)
+}
+
+// PatchURL handles partially updating a URL with a JSON merge patch
+func (h *URLHandler) PatchURL(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid URL ID")
+		return
+	}
+
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		api.PreconditionFailed(w, services.ErrVersionMismatch.Error())
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.Error("Failed to read request body: %v", err)
+		api.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	url, err := h.urlService.PatchURL(r.Context(), userID, uint(id), patch, version)
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			api.NotFound(w, "URL not found")
+			return
+		}
+		if writeURLError(w, err) {
+			return
+		}
+		logger.Error("Failed to patch URL: %v", err)
+		api.InternalError(w, "Failed to update URL")
+		return
+	}
+
+	setETag(w, url)
 	api.Success(w, url)
 }
 
@@ -135,6 +261,107 @@ func (h *URLHandler) DeleteURL(w http.ResponseWriter, r *http.Request) {
 	api.Success(w, nil)
 }
 
+// ListTrash handles listing the user's deleted URLs
+func (h *URLHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+
+	urls, err := h.urlService.ListTrash(r.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to list deleted URLs: %v", err)
+		api.InternalError(w, "Failed to list deleted URLs")
+		return
+	}
+
+	api.Success(w, urls)
+}
+
+// RestoreURL handles taking a URL out of the trash
+func (h *URLHandler) RestoreURL(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid URL ID")
+		return
+	}
+
+	url, err := h.urlService.RestoreURL(r.Context(), userID, uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			api.NotFound(w, "URL not found")
+			return
+		}
+		logger.Error("Failed to restore URL: %v", err)
+		api.InternalError(w, "Failed to restore URL")
+		return
+	}
+
+	setETag(w, url)
+	api.Success(w, url)
+}
+
+// GetURLHistory handles listing the edits made to a URL. Only field edits
+// are listed, not trashing, restoring from the trash or admin takedowns.
+func (h *URLHandler) GetURLHistory(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid URL ID")
+		return
+	}
+
+	history, err := h.urlService.GetURLHistory(r.Context(), userID, uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			api.NotFound(w, "URL not found")
+			return
+		}
+		logger.Error("Failed to get URL history: %v", err)
+		api.InternalError(w, "Failed to get URL history")
+		return
+	}
+
+	api.Success(w, history)
+}
+
+// RestoreRevision handles rolling a URL back to an earlier revision
+func (h *URLHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint)
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		api.BadRequest(w, "Invalid URL ID")
+		return
+	}
+	revision, err := strconv.ParseInt(vars["rev"], 10, 64)
+	if err != nil {
+		api.BadRequest(w, "Invalid revision")
+		return
+	}
+
+	url, err := h.urlService.RestoreRevision(r.Context(), userID, uint(id), revision)
+	if err != nil {
+		if errors.Is(err, services.ErrURLNotFound) {
+			api.NotFound(w, "URL not found")
+			return
+		}
+		if errors.Is(err, services.ErrRevisionNotFound) {
+			api.NotFound(w, "Revision not found")
+			return
+		}
+		if writeURLError(w, err) {
+			return
+		}
+		logger.Error("Failed to restore URL revision: %v", err)
+		api.InternalError(w, "Failed to restore URL revision")
+		return
+	}
+
+	setETag(w, url)
+	api.Success(w, url)
+}
+
 // RedirectToOriginal handles redirecting to the original URL
 func (h *URLHandler) RedirectToOriginal(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -151,5 +378,101 @@ func (h *URLHandler) RedirectToOriginal(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	http.Redirect(w, r, url.OriginalURL, http.StatusMovedPermanently)
+	// A link taken down by an admin must not fall back elsewhere either
+	if url.Disabled {
+		api.Error(w, http.StatusGone, "URL has been disabled")
+		return
+	}
+
+	if services.URLExpired(url, time.Now()) {
+		writeExpired(w, r, url)
+		return
+	}
+
+	if url.PasswordProtected {
+		cookie, err := r.Cookie(unlockCookieName(url.ID))
+		if err != nil || !h.urlService.VerifyUnlockToken(url, cookie.Value) {
+			renderUnlockForm(w, http.StatusOK, "")
+			return
+		}
+	}
+
+	click := &models.ClickEvent{
+		URLID:          url.ID,
+		ShortCode:      url.ShortCode,
+		Referrer:       r.Referer(),
+		UserAgent:      r.UserAgent(),
+		IPAddress:      h.trustedProxies.ClientIP(r),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+	}
+	if url.MaxClicks != nil {
+		// A click limit is only kept if the visit is counted before the
+		// redirect, so here a failed write does stop the visitor
+		err := h.urlService.ClaimClick(r.Context(), click)
+		if errors.Is(err, services.ErrClickLimitReached) {
+			writeExpired(w, r, url)
+			return
+		}
+		if err != nil {
+			logger.Error("Failed to claim click for %s: %v", shortCode, err)
+			api.InternalError(w, "Failed to record click")
+			return
+		}
+	} else if err := h.urlService.RecordClick(r.Context(), click); err != nil {
+		// A failed click write must not keep the visitor from their destination
+		logger.Error("Failed to record click for %s: %v", shortCode, err)
+	}
+
+	status := h.urlService.RedirectStatus(url)
+	w.Header().Set("Cache-Control", redirectCacheControl(status, url))
+	http.Redirect(w, r, url.OriginalURL, status)
+}
+
+// writeExpired sends visitors of an expired link to its fallback URL, or
+// tells them it is gone
+func writeExpired(w http.ResponseWriter, r *http.Request, url *models.URLResponse) {
+	if url.FallbackURL != "" {
+		w.Header().Set("Cache-Control", "private, no-store")
+		http.Redirect(w, r, url.FallbackURL, http.StatusFound)
+		return
+	}
+	api.Error(w, http.StatusGone, "URL has expired")
+}
+
+// redirectCacheControl matches caching to the redirect status. Permanent
+// redirects may be cached for a bounded time, temporary ones are never
+// cached so each visit reaches the server and is counted. Links with
+// expiry, click limits or a password always need a fresh check.
+func redirectCacheControl(status int, url *models.URLResponse) string {
+	permanent := status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+	if !permanent || url.ExpiresAt != nil || url.MaxClicks != nil || url.PasswordProtected {
+		return "private, no-store"
+	}
+	return "public, max-age=" + strconv.Itoa(int(permanentRedirectMaxAge.Seconds()))
+}
+
+// writeURLError responds to validation failures, short code conflicts, edits
+// based on a stale version and unverified users over their link limit,
+// reporting whether err was one of them
+func writeURLError(w http.ResponseWriter, err error) bool {
+	var conflict *services.ShortCodeConflictError
+	var fields validator.ValidationErrors
+	switch {
+	case errors.As(err, &conflict):
+		api.Conflict(w, conflict.Error(), conflict)
+	case errors.As(err, &fields):
+		api.ValidationFailed(w, fields)
+	case errors.Is(err, services.ErrVersionMismatch):
+		api.PreconditionFailed(w, err.Error())
+	case errors.Is(err, services.ErrInvalidPatch):
+		api.BadRequest(w, err.Error())
+	case errors.Is(err, services.ErrEmailNotVerified):
+		api.Forbidden(w, err.Error())
+	case errors.Is(err, services.ErrInvalidShortCode), errors.Is(err, services.ErrInvalidExpiry),
+		errors.Is(err, services.ErrInvalidRedirectStatus), errors.Is(err, services.ErrInvalidDestination):
+		api.BadRequest(w, err.Error())
+	default:
+		return false
+	}
+	return true
 }
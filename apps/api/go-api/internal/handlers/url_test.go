@@ -5,15 +5,19 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
 )
 
 type MockURLService struct {
@@ -36,7 +40,36 @@ func (m *MockURLService) GetURLByID(ctx context.Context, userID uint, urlID uint
 	return args.Get(0).(*models.URLResponse), args.Error(1)
 }
 
-func (m *MockURLService) GetUserURLs(ctx context.Context, userID uint) ([]models.URLResponse, error) {
+func (m *MockURLService) GetUserURLs(ctx context.Context, userID uint, query *models.URLListQuery) ([]models.URLResponse, *models.Pagination, error) {
+	args := m.Called(ctx, userID, query)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]models.URLResponse), args.Get(1).(*models.Pagination), args.Error(2)
+}
+
+func (m *MockURLService) UpdateURL(ctx context.Context, userID uint, urlID uint, req *models.UpdateURLRequest, version int64) (*models.URLResponse, error) {
+	args := m.Called(ctx, userID, urlID, req, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URLResponse), args.Error(1)
+}
+
+func (m *MockURLService) PatchURL(ctx context.Context, userID uint, urlID uint, patch []byte, version int64) (*models.URLResponse, error) {
+	args := m.Called(ctx, userID, urlID, patch, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URLResponse), args.Error(1)
+}
+
+func (m *MockURLService) DeleteURL(ctx context.Context, userID uint, urlID uint) error {
+	args := m.Called(ctx, userID, urlID)
+	return args.Error(0)
+}
+
+func (m *MockURLService) ListTrash(ctx context.Context, userID uint) ([]models.URLResponse, error) {
 	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -44,16 +77,64 @@ func (m *MockURLService) GetUserURLs(ctx context.Context, userID uint) ([]models
 	return args.Get(0).([]models.URLResponse), args.Error(1)
 }
 
-func (m *MockURLService) UpdateURL(ctx context.Context, userID uint, urlID uint, req *models.UpdateURLRequest) (*models.URLResponse, error) {
-	args := m.Called(ctx, userID, urlID, req)
+func (m *MockURLService) RestoreURL(ctx context.Context, userID uint, urlID uint) (*models.URLResponse, error) {
+	args := m.Called(ctx, userID, urlID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.URLResponse), args.Error(1)
 }
 
-func (m *MockURLService) DeleteURL(ctx context.Context, userID uint, urlID uint) error {
+func (m *MockURLService) GetURLHistory(ctx context.Context, userID uint, urlID uint) ([]models.URLRevisionResponse, error) {
 	args := m.Called(ctx, userID, urlID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.URLRevisionResponse), args.Error(1)
+}
+
+func (m *MockURLService) RestoreRevision(ctx context.Context, userID uint, urlID uint, revision int64) (*models.URLResponse, error) {
+	args := m.Called(ctx, userID, urlID, revision)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URLResponse), args.Error(1)
+}
+
+func (m *MockURLService) BulkCreateURLs(ctx context.Context, userID uint, reqs []models.CreateURLRequest) ([]models.BulkResult, error) {
+	args := m.Called(ctx, userID, reqs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BulkResult), args.Error(1)
+}
+
+func (m *MockURLService) BulkUpdateURLs(ctx context.Context, userID uint, items []models.BulkUpdateItem) ([]models.BulkResult, error) {
+	args := m.Called(ctx, userID, items)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BulkResult), args.Error(1)
+}
+
+func (m *MockURLService) BulkDeleteURLs(ctx context.Context, userID uint, ids []uint) ([]models.BulkResult, error) {
+	args := m.Called(ctx, userID, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BulkResult), args.Error(1)
+}
+
+func (m *MockURLService) ImportURLs(ctx context.Context, userID uint, records []models.ImportRecord, dryRun bool) (*models.ImportReport, error) {
+	args := m.Called(ctx, userID, records, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportReport), args.Error(1)
+}
+
+func (m *MockURLService) ExportURLs(ctx context.Context, userID uint, includeClicks bool, w services.URLExportWriter) error {
+	args := m.Called(ctx, userID, includeClicks, w)
 	return args.Error(0)
 }
 
@@ -65,6 +146,38 @@ func (m *MockURLService) GetURLByShortCode(ctx context.Context, shortCode string
 	return args.Get(0).(*models.URLResponse), args.Error(1)
 }
 
+func (m *MockURLService) RecordClick(ctx context.Context, click *models.ClickEvent) error {
+	args := m.Called(ctx, click)
+	return args.Error(0)
+}
+
+func (m *MockURLService) ClaimClick(ctx context.Context, click *models.ClickEvent) error {
+	args := m.Called(ctx, click)
+	return args.Error(0)
+}
+
+func (m *MockURLService) UnlockURL(ctx context.Context, shortCode, password, clientKey string) (*models.URLResponse, string, error) {
+	args := m.Called(ctx, shortCode, password, clientKey)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*models.URLResponse), args.String(1), args.Error(2)
+}
+
+func (m *MockURLService) VerifyUnlockToken(url *models.URLResponse, token string) bool {
+	args := m.Called(url, token)
+	return args.Bool(0)
+}
+
+func (m *MockURLService) UnlockTTL() time.Duration {
+	return time.Hour
+}
+
+func (m *MockURLService) RedirectStatus(url *models.URLResponse) int {
+	args := m.Called(url)
+	return args.Int(0)
+}
+
 func TestURLHandler_CreateURL(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -103,6 +216,57 @@ func TestURLHandler_CreateURL(t *testing.T) {
 			expectedField:  "error",
 			expectedValue:  "Invalid request body",
 		},
+		{
+			name: "short code taken",
+			requestBody: models.CreateURLRequest{
+				OriginalURL: "https://example.com",
+				ShortCode:   "abc123",
+			},
+			mockSetup: func(m *MockURLService) {
+				m.On("CreateURL", mock.Anything, uint(1), mock.AnythingOfType("*models.CreateURLRequest")).
+					Return(nil, &services.ShortCodeConflictError{ShortCode: "abc123", Suggestions: []string{"abc123-1"}})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedField:  "error",
+			expectedValue:  `short code "abc123" is already taken`,
+		},
+		{
+			name: "invalid short code",
+			requestBody: models.CreateURLRequest{
+				OriginalURL: "https://example.com",
+				ShortCode:   "a/b",
+			},
+			mockSetup: func(m *MockURLService) {
+				m.On("CreateURL", mock.Anything, uint(1), mock.AnythingOfType("*models.CreateURLRequest")).
+					Return(nil, fmt.Errorf("%w: only letters, digits, '-' and '_' are allowed", services.ErrInvalidShortCode))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "error",
+			expectedValue:  "invalid short code: only letters, digits, '-' and '_' are allowed",
+		},
+		{
+			name: "missing original url",
+			requestBody: models.CreateURLRequest{
+				Title: "Example",
+			},
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedField:  "error",
+			expectedValue:  "Validation failed",
+		},
+		{
+			name: "blocked destination",
+			requestBody: models.CreateURLRequest{
+				OriginalURL: "https://bad.example.net",
+			},
+			mockSetup: func(m *MockURLService) {
+				m.On("CreateURL", mock.Anything, uint(1), mock.AnythingOfType("*models.CreateURLRequest")).
+					Return(nil, services.ErrBlockedDomain)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "error",
+			expectedValue:  "invalid destination: domain is blocked",
+		},
 		{
 			name: "service error",
 			requestBody: models.CreateURLRequest{
@@ -216,3 +380,341 @@ func TestURLHandler_GetURL(t *testing.T) {
 		})
 	}
 }
+
+func TestURLHandler_GetUserURLs(t *testing.T) {
+	tests := []struct {
+		name           string
+		rawQuery       string
+		mockSetup      func(*MockURLService)
+		expectedStatus int
+		expectedMeta   interface{}
+	}{
+		{
+			name:     "success",
+			rawQuery: "limit=1&sort=clicks&order=asc&min_clicks=3&search=promo&created_from=2026-10-01",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetUserURLs", mock.Anything, uint(1), mock.MatchedBy(func(q *models.URLListQuery) bool {
+					return q.Limit == 1 && q.Sort == "clicks" && q.Order == "asc" && *q.MinClicks == 3 &&
+						q.Search == "promo" && q.CreatedFrom.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+				})).Return([]models.URLResponse{{ID: 1, ShortCode: "promo"}}, &models.Pagination{Limit: 1, NextCursor: "next", HasMore: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMeta: map[string]interface{}{
+				"pagination": map[string]interface{}{"limit": float64(1), "next_cursor": "next", "has_more": true},
+			},
+		},
+		{
+			name:           "invalid limit",
+			rawQuery:       "limit=ten",
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid query",
+			rawQuery: "sort=title",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetUserURLs", mock.Anything, uint(1), mock.Anything).
+					Return(nil, nil, services.ErrInvalidListQuery)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			tt.mockSetup(mockService)
+			handler := NewURLHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/urls?"+tt.rawQuery, nil)
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			w := httptest.NewRecorder()
+
+			handler.GetUserURLs(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var resp map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+			assert.Equal(t, tt.expectedMeta, resp["meta"])
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_RestoreURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		urlID          string
+		mockSetup      func(*MockURLService)
+		expectedStatus int
+	}{
+		{
+			name:  "restored",
+			urlID: "3",
+			mockSetup: func(m *MockURLService) {
+				m.On("RestoreURL", mock.Anything, uint(1), uint(3)).
+					Return(&models.URLResponse{ID: 3, ShortCode: "back", Version: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "not in trash",
+			urlID: "4",
+			mockSetup: func(m *MockURLService) {
+				m.On("RestoreURL", mock.Anything, uint(1), uint(4)).
+					Return(nil, services.ErrURLNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			urlID:          "trash",
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			tt.mockSetup(mockService)
+			handler := NewURLHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/urls/"+tt.urlID+"/restore", nil)
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			req = mux.SetURLVars(req, map[string]string{"id": tt.urlID})
+			w := httptest.NewRecorder()
+
+			handler.RestoreURL(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_RestoreRevision(t *testing.T) {
+	tests := []struct {
+		name           string
+		revision       string
+		mockSetup      func(*MockURLService)
+		expectedStatus int
+		expectedETag   string
+	}{
+		{
+			name:     "restored",
+			revision: "2",
+			mockSetup: func(m *MockURLService) {
+				m.On("RestoreRevision", mock.Anything, uint(1), uint(7), int64(2)).
+					Return(&models.URLResponse{ID: 7, Version: 5}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"5"`,
+		},
+		{
+			name:     "unknown revision",
+			revision: "9",
+			mockSetup: func(m *MockURLService) {
+				m.On("RestoreRevision", mock.Anything, uint(1), uint(7), int64(9)).
+					Return(nil, services.ErrRevisionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:     "restored state no longer valid",
+			revision: "1",
+			mockSetup: func(m *MockURLService) {
+				m.On("RestoreRevision", mock.Anything, uint(1), uint(7), int64(1)).
+					Return(nil, services.ErrInvalidExpiry)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid revision",
+			revision:       "latest",
+			mockSetup:      func(m *MockURLService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			tt.mockSetup(mockService)
+			handler := NewURLHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/urls/7/history/"+tt.revision+"/restore", nil)
+			req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+			req = mux.SetURLVars(req, map[string]string{"id": "7", "rev": tt.revision})
+			w := httptest.NewRecorder()
+
+			handler.RestoreRevision(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_RedirectToOriginal(t *testing.T) {
+	one := int64(1)
+	tests := []struct {
+		name                 string
+		shortCode            string
+		mockSetup            func(*MockURLService)
+		expectedStatus       int
+		expectedLocation     string
+		expectedCacheControl string
+	}{
+		{
+			name:      "success",
+			shortCode: "abc123",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "abc123").
+					Return(&models.URLResponse{ID: 1, OriginalURL: "https://example.com", ShortCode: "abc123"}, nil)
+				m.On("RecordClick", mock.Anything, mock.MatchedBy(func(c *models.ClickEvent) bool {
+					return c.URLID == 1 && c.ShortCode == "abc123" && c.Referrer == "https://ref.example" &&
+						c.IPAddress == "203.0.113.7" && c.AcceptLanguage == "en-US"
+				})).Return(nil)
+				m.On("RedirectStatus", mock.Anything).Return(http.StatusFound)
+			},
+			expectedStatus:       http.StatusFound,
+			expectedLocation:     "https://example.com",
+			expectedCacheControl: "private, no-store",
+		},
+		{
+			name:      "permanent redirect is cacheable",
+			shortCode: "abc123",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "abc123").
+					Return(&models.URLResponse{ID: 1, OriginalURL: "https://example.com", ShortCode: "abc123", RedirectStatus: http.StatusPermanentRedirect}, nil)
+				m.On("RecordClick", mock.Anything, mock.Anything).Return(nil)
+				m.On("RedirectStatus", mock.Anything).Return(http.StatusPermanentRedirect)
+			},
+			expectedStatus:       http.StatusPermanentRedirect,
+			expectedLocation:     "https://example.com",
+			expectedCacheControl: "public, max-age=86400",
+		},
+		{
+			name:      "click recording failure still redirects",
+			shortCode: "abc123",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "abc123").
+					Return(&models.URLResponse{ID: 1, OriginalURL: "https://example.com", ShortCode: "abc123"}, nil)
+				m.On("RecordClick", mock.Anything, mock.Anything).Return(errors.New("db down"))
+				m.On("RedirectStatus", mock.Anything).Return(http.StatusMovedPermanently)
+			},
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://example.com",
+		},
+		{
+			name:      "expired",
+			shortCode: "old",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "old").
+					Return(&models.URLResponse{ID: 2, OriginalURL: "https://example.com", ShortCode: "old", Expired: true}, nil)
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:      "expired with fallback",
+			shortCode: "old",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "old").
+					Return(&models.URLResponse{ID: 2, OriginalURL: "https://example.com", ShortCode: "old", Expired: true, FallbackURL: "https://example.com/closed"}, nil)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com/closed",
+		},
+		{
+			name:      "click limit counted before the redirect",
+			shortCode: "once",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "once").
+					Return(&models.URLResponse{ID: 4, OriginalURL: "https://example.com/file", ShortCode: "once", MaxClicks: &one}, nil)
+				m.On("ClaimClick", mock.Anything, mock.MatchedBy(func(c *models.ClickEvent) bool { return c.URLID == 4 })).Return(nil)
+				m.On("RedirectStatus", mock.Anything).Return(http.StatusFound)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com/file",
+		},
+		{
+			// The cached count is still 0, as the first click is not flushed
+			name:      "click limit used up",
+			shortCode: "once",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "once").
+					Return(&models.URLResponse{ID: 4, OriginalURL: "https://example.com/file", ShortCode: "once", MaxClicks: &one}, nil)
+				m.On("ClaimClick", mock.Anything, mock.Anything).Return(services.ErrClickLimitReached)
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:      "click limit used up with fallback",
+			shortCode: "once",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "once").
+					Return(&models.URLResponse{ID: 4, OriginalURL: "https://example.com/file", ShortCode: "once", MaxClicks: &one, FallbackURL: "https://example.com/closed"}, nil)
+				m.On("ClaimClick", mock.Anything, mock.Anything).Return(services.ErrClickLimitReached)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com/closed",
+		},
+		{
+			name:      "click limit not checked",
+			shortCode: "once",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "once").
+					Return(&models.URLResponse{ID: 4, OriginalURL: "https://example.com/file", ShortCode: "once", MaxClicks: &one}, nil)
+				m.On("ClaimClick", mock.Anything, mock.Anything).Return(errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:      "disabled by an admin",
+			shortCode: "bad",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "bad").
+					Return(&models.URLResponse{ID: 3, OriginalURL: "https://example.com", ShortCode: "bad", Disabled: true, FallbackURL: "https://example.com/closed"}, nil)
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:      "not found",
+			shortCode: "missing",
+			mockSetup: func(m *MockURLService) {
+				m.On("GetURLByShortCode", mock.Anything, "missing").
+					Return(nil, errors.New("url not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockURLService)
+			tt.mockSetup(mockService)
+			proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+			require.NoError(t, err)
+			handler := NewURLHandler(mockService, WithTrustedProxies(proxies))
+
+			req := httptest.NewRequest(http.MethodGet, "/urls/go/"+tt.shortCode, nil)
+			req = mux.SetURLVars(req, map[string]string{"shortCode": tt.shortCode})
+			req.RemoteAddr = "10.0.0.2:41000"
+			req.Header.Set("Referer", "https://ref.example")
+			req.Header.Set("Accept-Language", "en-US")
+			req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+			w := httptest.NewRecorder()
+
+			handler.RedirectToOriginal(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			if tt.expectedCacheControl != "" {
+				assert.Equal(t, tt.expectedCacheControl, w.Header().Get("Cache-Control"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -2,17 +2,40 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
 )
 
-// Auth is a middleware that verifies the JWT token and sets the user ID in the context
-func Auth(authService *services.AuthService) func(http.Handler) http.Handler {
+// Auth is a middleware that authenticates the request with either an API key
+// in the X-API-Key header or a JWT token that has not been revoked, and sets
+// the user ID and role in the context. API keys are also set in the context,
+// for RequireScope; JWT claims are set for the session routes. Requests from
+// disabled accounts are rejected.
+func Auth(authService *services.AuthService, revocations *services.RevocationList, apiKeys services.APIKeyServiceInterface) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := r.Header.Get("X-API-Key"); key != "" {
+				apiKey, err := apiKeys.Authenticate(r.Context(), key)
+				if errors.Is(err, services.ErrInvalidAPIKey) {
+					api.Error(w, http.StatusUnauthorized, "Invalid or revoked API key")
+					return
+				}
+				if err != nil {
+					logger.Error("Failed to check API key: %v", err)
+					api.InternalError(w, "Failed to verify API key")
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), "api_key", apiKey)
+				serveAccount(w, r.WithContext(ctx), next, revocations, apiKey.UserID)
+				return
+			}
+
 			// Get the Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
@@ -27,16 +50,50 @@ func Auth(authService *services.AuthService) func(http.Handler) http.Handler {
 				return
 			}
 
-			// Verify the token and get the user ID
-			userID, err := authService.ValidateToken(parts[1])
+			// Verify the token and get its claims
+			claims, err := authService.ValidateToken(parts[1])
 			if err != nil {
 				api.Error(w, http.StatusUnauthorized, "Invalid or expired token")
 				return
 			}
 
-			// Set the user ID in the context
-			ctx := context.WithValue(r.Context(), "user_id", userID)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			revoked, err := revocations.IsRevoked(r.Context(), claims)
+			if err != nil {
+				logger.Error("Failed to check token revocation: %v", err)
+				api.InternalError(w, "Failed to verify token")
+				return
+			}
+			if revoked {
+				api.Error(w, http.StatusUnauthorized, "Invalid or expired token")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "token_claims", claims)
+			serveAccount(w, r.WithContext(ctx), next, revocations, claims.UserID)
 		})
 	}
 }
+
+// serveAccount passes the request of userID on to next, unless the account
+// was deleted or disabled
+func serveAccount(w http.ResponseWriter, r *http.Request, next http.Handler, revocations *services.RevocationList, userID uint) {
+	account, err := revocations.Account(r.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to check account status: %v", err)
+		api.InternalError(w, "Failed to verify account")
+		return
+	}
+	if account.Deleted {
+		api.Error(w, http.StatusUnauthorized, "Invalid or expired token")
+		return
+	}
+	if account.Disabled {
+		api.Forbidden(w, "Account is disabled")
+		return
+	}
+
+	// Set the user ID and role in the context
+	ctx := context.WithValue(r.Context(), "user_id", userID)
+	ctx = context.WithValue(ctx, "user_role", account.Role)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+)
+
+// maxIdempotentBodySize bounds the request bodies read for hashing
+const maxIdempotentBodySize = 1 << 20
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key header, instead of handling it again. It must run after
+// Auth, since keys are scoped to the user.
+func Idempotency(service services.IdempotencyServiceInterface) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > services.MaxIdempotencyKeyLength {
+				api.BadRequest(w, "Idempotency-Key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				api.BadRequest(w, "Invalid request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			userID := r.Context().Value("user_id").(uint)
+			record, err := service.Begin(r.Context(), userID, key, requestHash(r, body))
+			switch {
+			case errors.Is(err, services.ErrIdempotencyKeyMismatch):
+				api.Error(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+				return
+			case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+				api.Conflict(w, "A request with this Idempotency-Key is still in progress", nil)
+				return
+			case err != nil:
+				logger.Error("Failed to check idempotency key: %v", err)
+				api.InternalError(w, "Failed to check Idempotency-Key")
+				return
+			}
+
+			if record.StatusCode != 0 {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(record.StatusCode)
+				w.Write(record.ResponseBody)
+				return
+			}
+
+			// Store the response even if the client has gone away meanwhile
+			ctx := context.WithoutCancel(r.Context())
+			rec := &responseRecorder{ResponseWriter: w}
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				// The handler panicked; free the key for a retry
+				if err := service.Release(ctx, record); err != nil && !errors.Is(err, services.ErrIdempotencyLeaseLost) {
+					logger.Error("Failed to release idempotency key: %v", err)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+			completed = true
+
+			// Server errors are not final, so a retry should run the request again
+			if rec.status() >= http.StatusInternalServerError {
+				err = service.Release(ctx, record)
+			} else {
+				err = service.Complete(ctx, record, rec.status(), rec.body.Bytes())
+			}
+			switch {
+			case errors.Is(err, services.ErrIdempotencyLeaseLost):
+				// A retry took the key over while this request ran too long;
+				// its response is the one that will be stored
+				logger.Info("Idempotency key %q was taken over by a retry; response not stored", key)
+			case err != nil:
+				logger.Error("Failed to store idempotent response: %v", err)
+			}
+		})
+	}
+}
+
+// requestHash identifies a request by its method, path and body. JSON bodies
+// are compared by content, so a retry may reorder fields or change spacing.
+func requestHash(r *http.Request, body []byte) string {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err == nil {
+		if canonical, err := json.Marshal(value); err == nil {
+			body = canonical
+		}
+	}
+
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copies the status and body written to a response
+type responseRecorder struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.code == 0 {
+		rec.code = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	if rec.code == 0 {
+		rec.code = http.StatusOK
+	}
+	rec.body.Write(p)
+	return rec.ResponseWriter.Write(p)
+}
+
+func (rec *responseRecorder) status() int {
+	if rec.code == 0 {
+		return http.StatusOK
+	}
+	return rec.code
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+// memoryIdempotency keeps idempotency keys in a map
+type memoryIdempotency struct {
+	records map[string]*models.IdempotencyKey
+}
+
+func (m *memoryIdempotency) Begin(ctx context.Context, userID uint, key string, requestHash string) (*models.IdempotencyKey, error) {
+	if record, ok := m.records[key]; ok {
+		if record.RequestHash != requestHash {
+			return nil, services.ErrIdempotencyKeyMismatch
+		}
+		if record.StatusCode == 0 {
+			return nil, services.ErrIdempotencyKeyInProgress
+		}
+		return record, nil
+	}
+	record := &models.IdempotencyKey{Owner: userID, Key: key, RequestHash: requestHash}
+	m.records[key] = record
+	return record, nil
+}
+
+func (m *memoryIdempotency) Complete(ctx context.Context, record *models.IdempotencyKey, statusCode int, body []byte) error {
+	if m.records[record.Key] != record {
+		return services.ErrIdempotencyLeaseLost
+	}
+	record.StatusCode = statusCode
+	record.ResponseBody = body
+	return nil
+}
+
+func (m *memoryIdempotency) Release(ctx context.Context, record *models.IdempotencyKey) error {
+	if m.records[record.Key] != record {
+		return services.ErrIdempotencyLeaseLost
+	}
+	delete(m.records, record.Key)
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	store := &memoryIdempotency{records: map[string]*models.IdempotencyKey{}}
+	calls := 0
+	status := http.StatusOK
+	handler := Idempotency(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":1}`))
+	}))
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/urls", bytes.NewBufferString(body))
+		req.Header.Set("Idempotency-Key", key)
+		req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("k1", `{"original_url":"https://example.com","title":"a"}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	// Reordered fields are the same request
+	replay := send("k1", `{"title":"a", "original_url":"https://example.com"}`)
+	assert.Equal(t, http.StatusOK, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, `{"id":1}`, replay.Body.String())
+	assert.Equal(t, 1, calls)
+
+	changed := send("k1", `{"original_url":"https://example.org"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, changed.Code)
+	assert.Equal(t, 1, calls)
+
+	// Server errors are not stored, so the retry runs again
+	status = http.StatusInternalServerError
+	assert.Equal(t, http.StatusInternalServerError, send("k2", `{}`).Code)
+	status = http.StatusOK
+	assert.Equal(t, http.StatusOK, send("k2", `{}`).Code)
+	assert.Equal(t, 3, calls)
+
+	// Requests without a key are never deduplicated
+	req := httptest.NewRequest(http.MethodPost, "/api/urls", bytes.NewBufferString(`{}`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, 4, calls)
+}
+
+func TestIdempotency_LeaseLost(t *testing.T) {
+	store := &memoryIdempotency{records: map[string]*models.IdempotencyKey{}}
+	retry := &models.IdempotencyKey{Owner: 1, Key: "k1"}
+	status := http.StatusOK
+	handler := Idempotency(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A retry takes the key over while this request is still running
+		store.records["k1"] = retry
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":1}`))
+	}))
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/urls", bytes.NewBufferString(`{}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		req = req.WithContext(context.WithValue(req.Context(), "user_id", uint(1)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send().Code)
+	assert.Same(t, retry, store.records["k1"])
+	assert.Equal(t, 0, retry.StatusCode, "the response is not stored on the retry's claim")
+
+	// Nor does a failed request free the retry's claim
+	delete(store.records, "k1")
+	status = http.StatusInternalServerError
+	assert.Equal(t, http.StatusInternalServerError, send().Code)
+	assert.Same(t, retry, store.records["k1"])
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/api"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// RequireScope is a middleware that rejects requests made with an API key
+// that was not granted scope. Requests made with a JWT token may use every
+// scope. It must run after Auth.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := r.Context().Value("api_key").(*models.APIKey); ok && !key.HasScope(scope) {
+				api.Forbidden(w, "API key is missing the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SessionOnly is a middleware that rejects requests made with an API key, for
+// routes that manage the account itself. It must run after Auth.
+func SessionOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value("api_key").(*models.APIKey); ok {
+			api.Forbidden(w, "API keys cannot be used for this endpoint")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin is a middleware that rejects requests from users without the
+// admin role. It must run after Auth.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if role, _ := r.Context().Value("user_role").(string); role != models.RoleAdmin {
+			api.Forbidden(w, "Admin access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
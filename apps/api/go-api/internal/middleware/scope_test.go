@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/services"
+)
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		apiKey         *models.APIKey
+		expectedStatus int
+	}{
+		{name: "session", expectedStatus: http.StatusOK},
+		{name: "key with scope", apiKey: &models.APIKey{Scopes: "stats:read,urls:write"}, expectedStatus: http.StatusOK},
+		{name: "key without scope", apiKey: &models.APIKey{Scopes: "urls:read"}, expectedStatus: http.StatusForbidden},
+	}
+
+	handler := RequireScope(services.ScopeURLsWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/urls", nil)
+			if tt.apiKey != nil {
+				req = req.WithContext(context.WithValue(req.Context(), "api_key", tt.apiKey))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestSessionOnly(t *testing.T) {
+	handler := SessionOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/keys", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "api_key", &models.APIKey{Scopes: "urls:write"}))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name           string
+		role           string
+		expectedStatus int
+	}{
+		{name: "admin", role: models.RoleAdmin, expectedStatus: http.StatusOK},
+		{name: "user", role: models.RoleUser, expectedStatus: http.StatusForbidden},
+		{name: "no role", expectedStatus: http.StatusForbidden},
+	}
+
+	handler := RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+			if tt.role != "" {
+				req = req.WithContext(context.WithValue(req.Context(), "user_role", tt.role))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// UserListQuery selects a page of users for the admin listing
+type UserListQuery struct {
+	Limit int
+	// Cursor continues the listing after the last user of a previous page
+	Cursor string
+	// Search matches name and email, ignoring case
+	Search string
+}
+
+// AdminURLListQuery selects a page of every user's links
+type AdminURLListQuery struct {
+	URLListQuery
+	// Owner keeps the links of one user; 0 keeps everyone's
+	Owner uint
+}
+
+type AdminUserResponse struct {
+	ID            uint       `json:"id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	EmailVerified bool       `json:"email_verified"`
+	Disabled      bool       `json:"disabled"`
+	DisabledAt    *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type AdminURLResponse struct {
+	URLResponse
+	Owner uint `json:"owner"`
+}
+
+type DisableURLRequest struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+type BlockDomainRequest struct {
+	Domain string `json:"domain" validate:"required,max=255"`
+	Reason string `json:"reason"`
+}
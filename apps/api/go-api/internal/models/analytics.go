@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// StatsQuery selects the range and bucket size of URL stats
+type StatsQuery struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+}
+
+// TimeBucket is the number of clicks in one interval of a time series
+type TimeBucket struct {
+	Bucket time.Time `json:"bucket"`
+	Clicks int64     `json:"clicks"`
+}
+
+// StatCount is the number of clicks sharing one value, e.g. one browser
+type StatCount struct {
+	Name   string `json:"name"`
+	Clicks int64  `json:"clicks"`
+}
+
+// ClickStats breaks down the clicks in a range
+type ClickStats struct {
+	From             time.Time    `json:"from"`
+	To               time.Time    `json:"to"`
+	Interval         string       `json:"interval"`
+	TotalClicks      int64        `json:"total_clicks"`
+	UniqueVisitors   int64        `json:"unique_visitors"`
+	TimeSeries       []TimeBucket `json:"time_series"`
+	Referrers        []StatCount  `json:"referrers"`
+	Browsers         []StatCount  `json:"browsers"`
+	OperatingSystems []StatCount  `json:"operating_systems"`
+	Devices          []StatCount  `json:"devices"`
+	Countries        []StatCount  `json:"countries"`
+}
+
+type URLStats struct {
+	URLID uint `json:"url_id"`
+	ClickStats
+}
+
+// TagStats rolls up the clicks of every link carrying a tag
+type TagStats struct {
+	TagID uint   `json:"tag_id"`
+	Tag   string `json:"tag"`
+	Links int64  `json:"links"`
+	ClickStats
+	// TopLinks names links by short code
+	TopLinks []StatCount `json:"top_links"`
+}
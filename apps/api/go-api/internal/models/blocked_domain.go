@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// BlockedDomain is a destination domain, including its subdomains, that links
+// may not point to
+type BlockedDomain struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Domain    string    `json:"domain" gorm:"uniqueIndex;not null"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the BlockedDomain model
+func (BlockedDomain) TableName() string {
+	return "blocked_domains"
+}
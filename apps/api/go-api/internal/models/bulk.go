@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// BulkUpdateItem is one link to change in a bulk update. Changes is a JSON
+// merge patch, and a non-zero Version must match the stored one.
+type BulkUpdateItem struct {
+	ID      uint            `json:"id"`
+	Version int64           `json:"version"`
+	Changes json.RawMessage `json:"changes"`
+}
+
+type BulkDeleteRequest struct {
+	IDs []uint `json:"ids" validate:"required"`
+}
+
+// BulkResult is the outcome of one operation in a bulk request. Index is the
+// position of the operation in the request.
+type BulkResult struct {
+	Index   int          `json:"index"`
+	Success bool         `json:"success"`
+	ID      uint         `json:"id,omitempty"`
+	URL     *URLResponse `json:"url,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// BulkResponse summarizes a bulk request
+type BulkResponse struct {
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// NewBulkResponse counts the outcomes in results
+func NewBulkResponse(results []BulkResult) *BulkResponse {
+	resp := &BulkResponse{Results: results}
+	for _, result := range results {
+		if result.Success {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp
+}
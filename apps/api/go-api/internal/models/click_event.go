@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ClickEvent records a single visit to a short URL
+type ClickEvent struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	URLID          uint      `json:"url_id" gorm:"column:url_id;not null;index"`
+	ShortCode      string    `json:"short_code" gorm:"not null"`
+	Referrer       string    `json:"referrer"`
+	UserAgent      string    `json:"user_agent"`
+	IPAddress      string    `json:"ip_address" gorm:"column:ip_address"`
+	AcceptLanguage string    `json:"accept_language"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the ClickEvent model
+func (ClickEvent) TableName() string {
+	return "click_events"
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// Folder holds links. Folders nest under at most one parent folder.
+type Folder struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Owner     uint      `json:"owner" gorm:"column:owner;not null"`
+	ParentID  *uint     `json:"parent_id"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FolderRequest creates, renames or moves a folder; a nil ParentID places
+// it at the top level
+type FolderRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+// URLFolderRequest moves a link into a folder, or out of all folders when
+// FolderID is nil
+type URLFolderRequest struct {
+	FolderID *uint `json:"folder_id"`
+}
+
+type FolderResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	ParentID  *uint     `json:"parent_id"`
+	Links     int64     `json:"links"`
+	CreatedAt time.Time `json:"created_at"`
+}
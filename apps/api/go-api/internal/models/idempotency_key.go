@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header so a retry of it can be answered the same way
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primaryKey"`
+	Owner       uint   `gorm:"column:owner;not null"`
+	Key         string `gorm:"not null"`
+	RequestHash string `gorm:"not null"`
+	// StatusCode is 0 while the first request is still being handled
+	StatusCode int
+	// LockedUntil is when the claim of a request still being handled lapses
+	LockedUntil  time.Time `gorm:"not null"`
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time `gorm:"not null"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// Outcomes of one record in an import
+const (
+	// ImportReady means a dry run found nothing stopping the record
+	ImportReady    = "ready"
+	ImportCreated  = "created"
+	ImportConflict = "conflict"
+	ImportInvalid  = "invalid"
+	ImportFailed   = "failed"
+)
+
+// ImportRecord is one link read from another shortener's export
+type ImportRecord struct {
+	// Row is the position of the record in its source, counting from 1
+	Row         int
+	ShortCode   string
+	OriginalURL string
+	Title       string
+	CreatedAt   *time.Time
+	// Error is set when the record could not be read in full
+	Error string
+}
+
+type ImportResult struct {
+	Row         int    `json:"row"`
+	ShortCode   string `json:"short_code"`
+	OriginalURL string `json:"original_url"`
+	Status      string `json:"status"`
+	ID          uint   `json:"id,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ImportReport lists the outcome of every record in an import. On a dry run
+// Imported counts the records that would have been created.
+type ImportReport struct {
+	DryRun    bool           `json:"dry_run"`
+	Imported  int            `json:"imported"`
+	Conflicts int            `json:"conflicts"`
+	Invalid   int            `json:"invalid"`
+	Failed    int            `json:"failed"`
+	Results   []ImportResult `json:"results"`
+}
+
+// Add records result and counts its outcome
+func (r *ImportReport) Add(result ImportResult) {
+	switch result.Status {
+	case ImportReady, ImportCreated:
+		r.Imported++
+	case ImportConflict:
+		r.Conflicts++
+	case ImportInvalid:
+		r.Invalid++
+	default:
+		r.Failed++
+	}
+	r.Results = append(r.Results, result)
+}
@@ -0,0 +1,8 @@
+package models
+
+// Pagination describes where a page sits in a cursor-paginated listing
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// RevokedToken is an access token that was logged out before it expired
+type RevokedToken struct {
+	JTI       string    `gorm:"column:jti;primaryKey"`
+	UserID    uint      `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+// LogoutRequest optionally names the refresh token to revoke along with the
+// access token used for the request
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// Tag labels any number of a user's links. Names are unique per user.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Owner     uint      `json:"owner" gorm:"column:owner;not null"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// URLTag attaches a tag to a link
+type URLTag struct {
+	URLID uint `gorm:"column:url_id;primaryKey"`
+	TagID uint `gorm:"primaryKey"`
+}
+
+// TableName specifies the table name for the URLTag model
+func (URLTag) TableName() string {
+	return "url_tags"
+}
+
+type TagRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+// URLTagsRequest replaces the tags of a link; unknown names are created
+type URLTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type TagResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Links     int64     `json:"links"`
+	CreatedAt time.Time `json:"created_at"`
+}
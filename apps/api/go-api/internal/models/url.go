@@ -2,38 +2,111 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type URL struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	OriginalURL string    `json:"original_url" gorm:"not null"`
-	ShortCode   string    `json:"short_code" gorm:"uniqueIndex"`
-	Title       string    `json:"title"`
-	Owner       uint      `json:"owner" gorm:"column:owner"`
-	User        User      `json:"user" gorm:"foreignKey:Owner"`
-	Clicks      int64     `json:"clicks" gorm:"default:0"`
-	CreatedAt   time.Time `json:"created_at"`
-	ClicksAt    time.Time `json:"clicks_at"`
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	OriginalURL string     `json:"original_url" gorm:"not null"`
+	ShortCode   string     `json:"short_code" gorm:"uniqueIndex"`
+	Title       string     `json:"title"`
+	Owner       uint       `json:"owner" gorm:"column:owner"`
+	User        User       `json:"user" gorm:"foreignKey:Owner"`
+	Clicks      int64      `json:"clicks" gorm:"default:0"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ClicksAt    time.Time  `json:"clicks_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	MaxClicks   *int64     `json:"max_clicks"`
+	FallbackURL string     `json:"fallback_url"`
+	Expired     bool       `json:"expired" gorm:"not null;default:false"`
+	Password    string     `json:"-" gorm:"column:password_hash"`
+	// RedirectStatus overrides the deployment default when non-zero
+	RedirectStatus int `json:"redirect_status"`
+	// Version is bumped on every edit and backs the URL's ETag
+	Version int64 `json:"version" gorm:"not null;default:1"`
+	// DeletedAt moves the link to the trash; its short code stays taken
+	// until the row is purged
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	FolderID  *uint          `json:"folder_id" gorm:"index"`
+	// DisabledAt is set when an admin takes the link down; the owner cannot
+	// clear it
+	DisabledAt     *time.Time `json:"-"`
+	DisabledReason string     `json:"-"`
 }
 
 type CreateURLRequest struct {
-	OriginalURL string `json:"original_url" validate:"required,url"`
-	Title       string `json:"title"`
-	ShortCode   string `json:"short_code"`
+	OriginalURL string     `json:"original_url" validate:"required,url"`
+	Title       string     `json:"title" validate:"max=255"`
+	ShortCode   string     `json:"short_code"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	MaxClicks   *int64     `json:"max_clicks" validate:"omitempty,min=1"`
+	FallbackURL string     `json:"fallback_url" validate:"omitempty,url"`
+	Password    string     `json:"password"`
+	// RedirectStatus is 301, 302, 307 or 308; 0 uses the deployment default
+	RedirectStatus int `json:"redirect_status"`
 }
 
 type UpdateURLRequest struct {
-	OriginalURL string `json:"original_url" validate:"required,url"`
-	Title       string `json:"title"`
-	ShortCode   string `json:"short_code"`
+	OriginalURL string     `json:"original_url" validate:"required,url"`
+	Title       string     `json:"title" validate:"max=255"`
+	ShortCode   string     `json:"short_code" validate:"required"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	MaxClicks   *int64     `json:"max_clicks" validate:"omitempty,min=1"`
+	FallbackURL string     `json:"fallback_url" validate:"omitempty,url"`
+	// Password replaces the link password when set; an empty string removes it
+	Password *string `json:"password"`
+	// RedirectStatus is 301, 302, 307 or 308; 0 uses the deployment default
+	RedirectStatus int `json:"redirect_status"`
+}
+
+// URLListQuery selects a page of a user's links. Zero values mean no filter.
+type URLListQuery struct {
+	Limit int
+	// Cursor continues the listing after the last link of a previous page
+	Cursor string
+	// Sort is created_at, clicks or clicks_at; Order is asc or desc
+	Sort        string
+	Order       string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	MinClicks   *int64
+	// Search matches title, short code and destination, ignoring case
+	Search string
+	// Tag keeps links carrying the named tag
+	Tag string
+	// FolderID keeps links directly in the folder; 0 keeps links in no folder
+	FolderID *uint
 }
 
 type URLResponse struct {
-	ID          uint      `json:"id"`
-	OriginalURL string    `json:"original_url"`
-	ShortCode   string    `json:"short_code"`
-	Title       string    `json:"title"`
-	Clicks      int64     `json:"clicks"`
-	CreatedAt   time.Time `json:"created_at"`
-	ClicksAt    time.Time `json:"clicks_at"`
+	ID          uint       `json:"id"`
+	OriginalURL string     `json:"original_url"`
+	ShortCode   string     `json:"short_code"`
+	Title       string     `json:"title"`
+	Clicks      int64      `json:"clicks"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ClicksAt    time.Time  `json:"clicks_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxClicks   *int64     `json:"max_clicks,omitempty"`
+	FallbackURL string     `json:"fallback_url,omitempty"`
+	Expired     bool       `json:"expired"`
+	// PasswordProtected is set when visitors must unlock the link first
+	PasswordProtected bool `json:"password_protected"`
+	// PasswordFingerprint identifies the current link password, so unlock
+	// tokens stop working when it changes
+	PasswordFingerprint string `json:"-"`
+	// RedirectStatus is 0 when the link uses the deployment default
+	RedirectStatus int `json:"redirect_status"`
+	// Version is also sent as the ETag of the URL resource
+	Version int64 `json:"version"`
+	// DeletedAt and PurgeAt are only set for links in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty"`
+	FolderID  *uint      `json:"folder_id,omitempty"`
+	// Tags is only filled in where links are read for display
+	Tags []string `json:"tags,omitempty"`
+	// Disabled links were taken down by an admin and no longer redirect
+	Disabled       bool   `json:"disabled"`
+	DisabledReason string `json:"disabled_reason,omitempty"`
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// URLRevision records one field changed by an edit of a URL. Revision is the
+// version of the URL the edit produced, so all fields changed together share
+// it. Values are stored as text and are nil where the field was unset.
+type URLRevision struct {
+	ID       uint  `json:"id" gorm:"primaryKey"`
+	URLID    uint  `json:"url_id" gorm:"column:url_id;not null"`
+	Revision int64 `json:"revision" gorm:"not null"`
+	// UserID is who made the edit, nil once their account is deleted
+	UserID    *uint     `json:"user_id"`
+	Field     string    `json:"field" gorm:"not null"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the URLRevision model
+func (URLRevision) TableName() string {
+	return "url_revisions"
+}
+
+// FieldChange is the old and new value of one field in a revision
+type FieldChange struct {
+	Field    string  `json:"field"`
+	OldValue *string `json:"old_value"`
+	NewValue *string `json:"new_value"`
+}
+
+// URLRevisionResponse groups the fields changed by one edit
+type URLRevisionResponse struct {
+	Revision  int64         `json:"revision"`
+	UserID    *uint         `json:"user_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Changes   []FieldChange `json:"changes"`
+}
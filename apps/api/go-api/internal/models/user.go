@@ -14,22 +14,42 @@ type User struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	// TokensRevokedAt rejects every access token issued up to then
+	TokensRevokedAt *time.Time `json:"-"`
+	Role            string     `json:"role" gorm:"not null;default:user"`
+	// DisabledAt is set while an admin has locked the account
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	// EmailVerifiedAt is set once the user confirms their email address
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 }
 
+// Roles a user can have
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type RegisterRequest struct {
-	Name     string `json:"name" validate:"required"`
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Name     string `json:"name" validate:"required,max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password"`
 }
 
+// LoginRequest does not apply the registration password rules, so accounts
+// created under older rules can still sign in
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
 
+// AuthResponse carries a short-lived access token, which authorizes API
+// calls, and the refresh token to exchange for the next one
 type AuthResponse struct {
 	Token string `json:"token"`
-	User  struct {
+	// ExpiresIn is the lifetime of Token in seconds
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	User         struct {
 		ID    uint   `json:"id"`
 		Name  string `json:"name"`
 		Email string `json:"email"`
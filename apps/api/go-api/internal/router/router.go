@@ -14,21 +14,45 @@ type Router struct {
 	healthHandler *handlers.HealthHandler
 	authHandler   *handlers.AuthHandler
 	urlHandler    *handlers.URLHandler
+	statsHandler  *handlers.AnalyticsHandler
+	tagHandler    *handlers.TagHandler
+	folderHandler *handlers.FolderHandler
+	apiKeyHandler *handlers.APIKeyHandler
+	adminHandler  *handlers.AdminHandler
 	authService   *services.AuthService
+	revocations   *services.RevocationList
+	idempotency   services.IdempotencyServiceInterface
+	apiKeys       services.APIKeyServiceInterface
 }
 
 func NewRouter(
 	healthHandler *handlers.HealthHandler,
 	authHandler *handlers.AuthHandler,
 	urlHandler *handlers.URLHandler,
+	statsHandler *handlers.AnalyticsHandler,
+	tagHandler *handlers.TagHandler,
+	folderHandler *handlers.FolderHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	adminHandler *handlers.AdminHandler,
 	authService *services.AuthService,
+	revocations *services.RevocationList,
+	idempotency services.IdempotencyServiceInterface,
+	apiKeys services.APIKeyServiceInterface,
 ) *Router {
 	r := &Router{
 		Router:        mux.NewRouter(),
 		healthHandler: healthHandler,
 		authHandler:   authHandler,
 		urlHandler:    urlHandler,
+		statsHandler:  statsHandler,
+		tagHandler:    tagHandler,
+		folderHandler: folderHandler,
+		apiKeyHandler: apiKeyHandler,
+		adminHandler:  adminHandler,
 		authService:   authService,
+		revocations:   revocations,
+		idempotency:   idempotency,
+		apiKeys:       apiKeys,
 	}
 
 	r.setupRoutes()
@@ -51,18 +75,85 @@ func (r *Router) setupRoutes() {
 	// Auth routes
 	api.HandleFunc("/auth/login", r.authHandler.Login).Methods(http.MethodPost)
 	api.HandleFunc("/auth/register", r.authHandler.Register).Methods(http.MethodPost)
+	api.HandleFunc("/auth/refresh", r.authHandler.Refresh).Methods(http.MethodPost)
+	api.HandleFunc("/auth/verify", r.authHandler.VerifyEmail).Methods(http.MethodGet)
+
+	// Redirect routes (public). Registered before the protected routes so
+	// short codes such as "history" or "restore" are not matched by
+	// /urls/{id}/... and answered with 401.
+	api.HandleFunc("/urls/go/{shortCode}", r.urlHandler.RedirectToOriginal).Methods(http.MethodGet).Name("redirect")
+	api.HandleFunc("/urls/go/{shortCode}", r.urlHandler.UnlockURL).Methods(http.MethodPost).Name("unlock")
 
 	// Protected routes
 	protected := api.PathPrefix("").Subrouter()
-	protected.Use(middleware.Auth(r.authService))
+	protected.Use(middleware.Auth(r.authService, r.revocations, r.apiKeys))
+
+	// Session and API key routes, which API keys cannot use
+	protected.Handle("/auth/logout", sessionOnly(r.authHandler.Logout)).Methods(http.MethodPost)
+	protected.Handle("/auth/logout/all", sessionOnly(r.authHandler.LogoutEverywhere)).Methods(http.MethodPost)
+	protected.Handle("/auth/verify/resend", sessionOnly(r.authHandler.ResendVerification)).Methods(http.MethodPost)
+	protected.Handle("/auth/keys", sessionOnly(r.apiKeyHandler.ListAPIKeys)).Methods(http.MethodGet)
+	protected.Handle("/auth/keys", sessionOnly(r.apiKeyHandler.CreateAPIKey)).Methods(http.MethodPost)
+	protected.Handle("/auth/keys/{id}", sessionOnly(r.apiKeyHandler.RevokeAPIKey)).Methods(http.MethodDelete)
 
 	// URL routes
-	protected.HandleFunc("/urls", r.urlHandler.CreateURL).Methods(http.MethodPost)
-	protected.HandleFunc("/urls/{id}", r.urlHandler.GetURL).Methods(http.MethodGet)
-	protected.HandleFunc("/urls", r.urlHandler.GetUserURLs).Methods(http.MethodGet)
-	protected.HandleFunc("/urls/{id}", r.urlHandler.UpdateURL).Methods(http.MethodPut)
-	protected.HandleFunc("/urls/{id}", r.urlHandler.DeleteURL).Methods(http.MethodDelete)
-
-	// Redirect routes (public)
-	api.HandleFunc("/urls/go/{shortCode}", r.urlHandler.RedirectToOriginal).Methods(http.MethodGet)
+	protected.Handle("/urls", middleware.RequireScope(services.ScopeURLsWrite)(
+		middleware.Idempotency(r.idempotency)(http.HandlerFunc(r.urlHandler.CreateURL)))).Methods(http.MethodPost)
+	// Registered before /urls/{id} so "trash", "bulk", "export" and "import"
+	// are not taken for IDs
+	protected.Handle("/urls/trash", scoped(services.ScopeURLsRead, r.urlHandler.ListTrash)).Methods(http.MethodGet)
+	protected.Handle("/urls/bulk", scoped(services.ScopeURLsWrite, r.urlHandler.BulkCreateURLs)).Methods(http.MethodPost)
+	protected.Handle("/urls/bulk", scoped(services.ScopeURLsWrite, r.urlHandler.BulkUpdateURLs)).Methods(http.MethodPatch)
+	protected.Handle("/urls/bulk", scoped(services.ScopeURLsWrite, r.urlHandler.BulkDeleteURLs)).Methods(http.MethodDelete)
+	protected.Handle("/urls/export", scoped(services.ScopeURLsRead, r.urlHandler.ExportURLs)).Methods(http.MethodGet)
+	protected.Handle("/urls/import", scoped(services.ScopeURLsWrite, r.urlHandler.ImportURLs)).Methods(http.MethodPost)
+	protected.Handle("/urls/{id}", scoped(services.ScopeURLsRead, r.urlHandler.GetURL)).Methods(http.MethodGet)
+	protected.Handle("/urls", scoped(services.ScopeURLsRead, r.urlHandler.GetUserURLs)).Methods(http.MethodGet)
+	protected.Handle("/urls/{id}", scoped(services.ScopeURLsWrite, r.urlHandler.UpdateURL)).Methods(http.MethodPut)
+	protected.Handle("/urls/{id}", scoped(services.ScopeURLsWrite, r.urlHandler.PatchURL)).Methods(http.MethodPatch)
+	protected.Handle("/urls/{id}", scoped(services.ScopeURLsWrite, r.urlHandler.DeleteURL)).Methods(http.MethodDelete)
+	protected.Handle("/urls/{id}/restore", scoped(services.ScopeURLsWrite, r.urlHandler.RestoreURL)).Methods(http.MethodPost)
+	protected.Handle("/urls/{id}/history", scoped(services.ScopeURLsRead, r.urlHandler.GetURLHistory)).Methods(http.MethodGet)
+	protected.Handle("/urls/{id}/history/{rev}/restore", scoped(services.ScopeURLsWrite, r.urlHandler.RestoreRevision)).Methods(http.MethodPost)
+	protected.Handle("/urls/{id}/tags", scoped(services.ScopeURLsWrite, r.tagHandler.SetURLTags)).Methods(http.MethodPut)
+	protected.Handle("/urls/{id}/folder", scoped(services.ScopeURLsWrite, r.folderHandler.MoveURL)).Methods(http.MethodPut)
+
+	// Tag and folder routes
+	protected.Handle("/tags", scoped(services.ScopeURLsRead, r.tagHandler.ListTags)).Methods(http.MethodGet)
+	protected.Handle("/tags", scoped(services.ScopeURLsWrite, r.tagHandler.CreateTag)).Methods(http.MethodPost)
+	protected.Handle("/tags/{id}", scoped(services.ScopeURLsWrite, r.tagHandler.UpdateTag)).Methods(http.MethodPut)
+	protected.Handle("/tags/{id}", scoped(services.ScopeURLsWrite, r.tagHandler.DeleteTag)).Methods(http.MethodDelete)
+	protected.Handle("/folders", scoped(services.ScopeURLsRead, r.folderHandler.ListFolders)).Methods(http.MethodGet)
+	protected.Handle("/folders", scoped(services.ScopeURLsWrite, r.folderHandler.CreateFolder)).Methods(http.MethodPost)
+	protected.Handle("/folders/{id}", scoped(services.ScopeURLsWrite, r.folderHandler.UpdateFolder)).Methods(http.MethodPut)
+	protected.Handle("/folders/{id}", scoped(services.ScopeURLsWrite, r.folderHandler.DeleteFolder)).Methods(http.MethodDelete)
+
+	// Analytics routes
+	protected.Handle("/urls/{id}/stats", scoped(services.ScopeStatsRead, r.statsHandler.GetURLStats)).Methods(http.MethodGet)
+	protected.Handle("/tags/{id}/stats", scoped(services.ScopeStatsRead, r.statsHandler.GetTagStats)).Methods(http.MethodGet)
+
+	// Admin routes, for sessions of admins only
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.SessionOnly)
+	admin.Use(middleware.RequireAdmin)
+	admin.HandleFunc("/users", r.adminHandler.ListUsers).Methods(http.MethodGet)
+	admin.HandleFunc("/users/{id}/disable", r.adminHandler.DisableUser).Methods(http.MethodPost)
+	admin.HandleFunc("/users/{id}/enable", r.adminHandler.EnableUser).Methods(http.MethodPost)
+	admin.HandleFunc("/urls", r.adminHandler.ListURLs).Methods(http.MethodGet)
+	admin.HandleFunc("/urls/{shortCode}/disable", r.adminHandler.DisableURL).Methods(http.MethodPost)
+	admin.HandleFunc("/urls/{shortCode}/enable", r.adminHandler.EnableURL).Methods(http.MethodPost)
+	admin.HandleFunc("/blocked-domains", r.adminHandler.ListBlockedDomains).Methods(http.MethodGet)
+	admin.HandleFunc("/blocked-domains", r.adminHandler.BlockDomain).Methods(http.MethodPost)
+	admin.HandleFunc("/blocked-domains/{domain}", r.adminHandler.UnblockDomain).Methods(http.MethodDelete)
+
+}
+
+// scoped limits handler to sessions and API keys granted scope
+func scoped(scope string, handler http.HandlerFunc) http.Handler {
+	return middleware.RequireScope(scope)(handler)
+}
+
+// sessionOnly limits handler to sessions, keeping API keys out
+func sessionOnly(handler http.HandlerFunc) http.Handler {
+	return middleware.SessionOnly(handler)
 }
@@ -0,0 +1,31 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/handlers"
+)
+
+func TestRouter_PublicRoutesWinOverSubResources(t *testing.T) {
+	r := NewRouter(&handlers.HealthHandler{}, &handlers.AuthHandler{}, &handlers.URLHandler{}, &handlers.AnalyticsHandler{},
+		&handlers.TagHandler{}, &handlers.FolderHandler{}, &handlers.APIKeyHandler{}, &handlers.AdminHandler{},
+		nil, nil, nil, nil)
+
+	// Short codes that are also words used in /urls/{id}/... paths
+	for _, code := range []string{"history", "restore", "stats", "tags", "folder", "abc123"} {
+		for method, route := range map[string]string{http.MethodGet: "redirect", http.MethodPost: "unlock"} {
+			req := httptest.NewRequest(method, "/api/urls/go/"+code, nil)
+			var match mux.RouteMatch
+			require.True(t, r.Match(req, &match), "%s %s", method, code)
+			require.NotNil(t, match.Route, "%s %s", method, code)
+			assert.Equal(t, route, match.Route.GetName(), "%s %s", method, code)
+			assert.Equal(t, code, match.Vars["shortCode"])
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrCannotDisableSelf = errors.New("admins cannot disable their own account")
+)
+
+type AdminServiceInterface interface {
+	ListUsers(ctx context.Context, query *models.UserListQuery) ([]models.AdminUserResponse, *models.Pagination, error)
+	SetUserDisabled(ctx context.Context, adminID, userID uint, disabled bool) (*models.AdminUserResponse, error)
+	ListURLs(ctx context.Context, query *models.AdminURLListQuery) ([]models.AdminURLResponse, *models.Pagination, error)
+	SetURLDisabled(ctx context.Context, shortCode string, disabled bool, reason string) (*models.AdminURLResponse, error)
+	ListBlockedDomains(ctx context.Context) ([]models.BlockedDomain, error)
+	BlockDomain(ctx context.Context, domain, reason string) (*models.BlockedDomain, error)
+	UnblockDomain(ctx context.Context, domain string) error
+}
+
+// AdminService moderates accounts and links across every user
+type AdminService struct {
+	db          *gorm.DB
+	urls        *URLService
+	revocations *RevocationList
+	now         func() time.Time
+}
+
+func NewAdminService(db *gorm.DB, urls *URLService, revocations *RevocationList) *AdminService {
+	return &AdminService{
+		db:          db,
+		urls:        urls,
+		revocations: revocations,
+		now:         time.Now,
+	}
+}
+
+// ListUsers returns one page of users, newest first
+func (s *AdminService) ListUsers(ctx context.Context, query *models.UserListQuery) ([]models.AdminUserResponse, *models.Pagination, error) {
+	switch {
+	case query.Limit < 0:
+		return nil, nil, fmt.Errorf("%w: limit must be positive", ErrInvalidListQuery)
+	case query.Limit == 0:
+		query.Limit = DefaultURLPageSize
+	case query.Limit > MaxURLPageSize:
+		query.Limit = MaxURLPageSize
+	}
+
+	db := s.db.WithContext(ctx)
+	if search := strings.TrimSpace(query.Search); search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		db = db.Where("name ILIKE ? OR email ILIKE ?", pattern, pattern)
+	}
+	if query.Cursor != "" {
+		// The cursor is the ID of the last user on the previous page
+		lastID, err := strconv.ParseUint(query.Cursor, 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid cursor", ErrInvalidListQuery)
+		}
+		db = db.Where("id < ?", lastID)
+	}
+
+	// One extra row tells whether another page follows
+	var users []models.User
+	if err := db.Order("id DESC").Limit(query.Limit + 1).Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+
+	page := &models.Pagination{Limit: query.Limit}
+	if len(users) > query.Limit {
+		users = users[:query.Limit]
+		page.HasMore = true
+		page.NextCursor = strconv.FormatUint(uint64(users[len(users)-1].ID), 10)
+	}
+
+	responses := make([]models.AdminUserResponse, len(users))
+	for i := range users {
+		responses[i] = toAdminUserResponse(&users[i])
+	}
+	return responses, page, nil
+}
+
+// SetUserDisabled disables or re-enables the account of userID. Disabling
+// also revokes every token of the user, so re-enabled users log in again.
+func (s *AdminService) SetUserDisabled(ctx context.Context, adminID, userID uint, disabled bool) (*models.AdminUserResponse, error) {
+	if disabled && userID == adminID {
+		return nil, ErrCannotDisableSelf
+	}
+
+	var user models.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		if !disabled {
+			user.DisabledAt = nil
+			return tx.Model(&user).Update("disabled_at", nil).Error
+		}
+		if user.DisabledAt != nil {
+			return nil
+		}
+
+		now := s.now()
+		err := tx.Model(&user).Updates(map[string]interface{}{
+			"disabled_at":       now,
+			"tokens_revoked_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		user.DisabledAt = &now
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.revocations != nil {
+		s.revocations.Forget(userID)
+	}
+	resp := toAdminUserResponse(&user)
+	return &resp, nil
+}
+
+// ListURLs returns one page of every user's links, or of query.Owner's
+func (s *AdminService) ListURLs(ctx context.Context, query *models.AdminURLListQuery) ([]models.AdminURLResponse, *models.Pagination, error) {
+	urls, page, err := s.urls.listURLs(ctx, query.Owner, &query.URLListQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	responses := make([]models.URLResponse, len(urls))
+	for i, url := range urls {
+		responses[i] = *toURLResponse(&url)
+	}
+	if err := s.urls.attachTags(ctx, responses); err != nil {
+		return nil, nil, err
+	}
+
+	adminResponses := make([]models.AdminURLResponse, len(urls))
+	for i := range urls {
+		adminResponses[i] = models.AdminURLResponse{URLResponse: responses[i], Owner: urls[i].Owner}
+	}
+	return adminResponses, page, nil
+}
+
+// SetURLDisabled takes the link stored under shortCode down, or puts it back
+// up. Disabling is separate from the owner's edits and does not change the
+// link's version.
+func (s *AdminService) SetURLDisabled(ctx context.Context, shortCode string, disabled bool, reason string) (*models.AdminURLResponse, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).Where("short_code = ?", shortCode).First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"disabled_at": nil, "disabled_reason": ""}
+	url.DisabledAt, url.DisabledReason = nil, ""
+	if disabled {
+		now := s.now()
+		reason = strings.TrimSpace(reason)
+		updates["disabled_at"], updates["disabled_reason"] = now, reason
+		url.DisabledAt, url.DisabledReason = &now, reason
+	}
+	if err := s.db.WithContext(ctx).Model(&models.URL{}).Where("id = ?", url.ID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	s.urls.invalidate(url.ID)
+
+	return &models.AdminURLResponse{URLResponse: *toURLResponse(&url), Owner: url.Owner}, nil
+}
+
+// ListBlockedDomains returns the destination blocklist
+func (s *AdminService) ListBlockedDomains(ctx context.Context) ([]models.BlockedDomain, error) {
+	return s.urls.destinations.ListBlockedDomains(ctx)
+}
+
+// BlockDomain adds domain to the destination blocklist
+func (s *AdminService) BlockDomain(ctx context.Context, domain, reason string) (*models.BlockedDomain, error) {
+	return s.urls.destinations.BlockDomain(ctx, domain, reason)
+}
+
+// UnblockDomain removes domain from the destination blocklist
+func (s *AdminService) UnblockDomain(ctx context.Context, domain string) error {
+	return s.urls.destinations.UnblockDomain(ctx, domain)
+}
+
+func toAdminUserResponse(user *models.User) models.AdminUserResponse {
+	return models.AdminUserResponse{
+		ID:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Disabled:      user.DisabledAt != nil,
+		DisabledAt:    user.DisabledAt,
+		CreatedAt:     user.CreatedAt,
+	}
+}
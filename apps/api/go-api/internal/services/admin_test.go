@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestAdminService_ListUsers(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewAdminService(db, NewURLService(db), nil)
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE \(name ILIKE \$1 OR email ILIKE \$2\) AND id < \$3 AND "users"\."deleted_at" IS NULL ORDER BY id DESC LIMIT \$4`).
+		WithArgs(`%ann%`, `%ann%`, uint64(10), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "role", "disabled_at"}).
+			AddRow(9, "Ann", "ann@example.com", models.RoleAdmin, nil).
+			AddRow(7, "Joanna", "jo@example.com", models.RoleUser, time.Now()).
+			AddRow(4, "Annie", "annie@example.com", models.RoleUser, nil))
+
+	users, page, err := service.ListUsers(context.Background(), &models.UserListQuery{Limit: 2, Cursor: "10", Search: " ann "})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, models.RoleAdmin, users[0].Role)
+	assert.True(t, users[1].Disabled)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, "7", page.NextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, _, err = service.ListUsers(context.Background(), &models.UserListQuery{Cursor: "abc"})
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+}
+
+func TestAdminService_SetUserDisabled(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	t.Run("disable revokes tokens", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		revocations := NewRevocationList(db, time.Minute, time.Minute)
+		revocations.users[2] = userRevocationEntry{expiresAt: now.Add(time.Hour)}
+		service := NewAdminService(db, NewURLService(db), revocations)
+		service.now = func() time.Time { return now }
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"\."id" = \$1 AND "users"\."deleted_at" IS NULL ORDER BY "users"\."id" LIMIT \$2`).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "role"}).AddRow(2, "Bob", "bob@example.com", models.RoleUser))
+		mock.ExpectExec(`UPDATE "users" SET "disabled_at"=\$1,"tokens_revoked_at"=\$2,"updated_at"=\$3 WHERE "users"\."deleted_at" IS NULL AND "id" = \$4`).
+			WithArgs(now, now, sqlmock.AnyArg(), uint(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "refresh_tokens" SET "revoked_at"=\$1 WHERE user_id = \$2 AND revoked_at IS NULL`).
+			WithArgs(now, uint(2)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		user, err := service.SetUserDisabled(context.Background(), 1, 2, true)
+		require.NoError(t, err)
+		assert.True(t, user.Disabled)
+		assert.NotContains(t, revocations.users, uint(2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("admins cannot disable themselves", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewAdminService(db, NewURLService(db), nil)
+
+		_, err := service.SetUserDisabled(context.Background(), 1, 1, true)
+		assert.ErrorIs(t, err, ErrCannotDisableSelf)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewAdminService(db, NewURLService(db), nil)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "users"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		_, err := service.SetUserDisabled(context.Background(), 1, 5, false)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAdminService_ListURLs(t *testing.T) {
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	db, mock := setupTestDB(t)
+	service := NewAdminService(db, NewURLService(db), nil)
+
+	// Without an owner the listing spans every user, and tags match by name
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE id IN \(SELECT url_tags\.url_id FROM url_tags JOIN tags ON tags\.id = url_tags\.tag_id WHERE tags\.name = \$1\) AND "urls"\."deleted_at" IS NULL ORDER BY created_at DESC, id DESC LIMIT \$2`).
+		WithArgs("spam", DefaultURLPageSize+1).
+		WillReturnRows(urlRows().
+			AddRow(5, "https://e.example.com", "eee", "E", 3, 0, created, created).
+			AddRow(4, "https://d.example.com", "ddd", "D", 2, 0, created, created))
+	expectURLTags(mock, 5, "spam")
+
+	urls, page, err := service.ListURLs(context.Background(), &models.AdminURLListQuery{
+		URLListQuery: models.URLListQuery{Tag: "Spam"},
+	})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.Equal(t, uint(3), urls[0].Owner)
+	assert.Equal(t, []string{"spam"}, urls[0].Tags)
+	assert.Equal(t, uint(2), urls[1].Owner)
+	assert.False(t, page.HasMore)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminService_SetURLDisabled(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	db, mock := setupTestDB(t)
+	cache := NewRedirectCache(10, time.Minute)
+	urlService := NewURLService(db, WithRedirectCache(cache))
+	service := NewAdminService(db, urlService, nil)
+	service.now = func() time.Time { return now }
+
+	cache.Set(&models.URLResponse{ID: 4, ShortCode: "ddd", OriginalURL: "https://d.example.com"})
+
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE short_code = \$1 AND "urls"\."deleted_at" IS NULL ORDER BY "urls"\."id" LIMIT \$2`).
+		WithArgs("ddd", 1).
+		WillReturnRows(urlRows().AddRow(4, "https://d.example.com", "ddd", "D", 2, 0, now, now))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "urls" SET "disabled_at"=\$1,"disabled_reason"=\$2 WHERE id = \$3 AND "urls"\."deleted_at" IS NULL`).
+		WithArgs(now, "phishing", uint(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	url, err := service.SetURLDisabled(context.Background(), "ddd", true, " phishing ")
+	require.NoError(t, err)
+	assert.True(t, url.Disabled)
+	assert.Equal(t, "phishing", url.DisabledReason)
+	assert.Equal(t, uint(2), url.Owner)
+
+	_, cached := cache.Get("ddd")
+	assert.False(t, cached)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
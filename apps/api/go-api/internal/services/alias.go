@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxAliasSuggestions is the number of free alternatives offered on a conflict
+const maxAliasSuggestions = 3
+
+// DefaultReservedAliases are path segments and words that must never be
+// claimed as custom short codes, including the sub-resources of /urls
+var DefaultReservedAliases = []string{
+	"admin", "api", "auth", "bulk", "export", "folder", "go", "health", "help",
+	"history", "import", "login", "logout", "register", "restore", "settings",
+	"static", "stats", "support", "tags", "trash", "urls",
+}
+
+var (
+	ErrInvalidShortCode = errors.New("invalid short code")
+	aliasPattern        = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// ShortCodeConflictError is returned when a requested short code is taken
+type ShortCodeConflictError struct {
+	ShortCode   string   `json:"short_code"`
+	Suggestions []string `json:"suggestions"`
+}
+
+func (e *ShortCodeConflictError) Error() string {
+	return fmt.Sprintf("short code %q is already taken", e.ShortCode)
+}
+
+// AliasPolicy decides which custom short codes users may claim
+type AliasPolicy struct {
+	minLength int
+	maxLength int
+	reserved  map[string]struct{}
+}
+
+// NewAliasPolicy returns a policy accepting codes of minLength to maxLength
+// characters that are not in reserved, or in DefaultReservedAliases when
+// reserved is nil. Lengths are clamped to what the short_code column can hold.
+func NewAliasPolicy(minLength, maxLength int, reserved []string) *AliasPolicy {
+	if reserved == nil {
+		reserved = DefaultReservedAliases
+	}
+	if minLength < 1 {
+		minLength = 1
+	}
+	if maxLength <= 0 || maxLength > MaxShortCodeLength {
+		maxLength = MaxShortCodeLength
+	}
+
+	p := &AliasPolicy{
+		minLength: minLength,
+		maxLength: maxLength,
+		reserved:  make(map[string]struct{}, len(reserved)),
+	}
+	for _, word := range reserved {
+		if word = strings.TrimSpace(word); word != "" {
+			p.reserved[strings.ToLower(word)] = struct{}{}
+		}
+	}
+	return p
+}
+
+// Validate returns an ErrInvalidShortCode error describing why code is not
+// an acceptable alias
+func (p *AliasPolicy) Validate(code string) error {
+	if len(code) < p.minLength || len(code) > p.maxLength {
+		return fmt.Errorf("%w: must be between %d and %d characters", ErrInvalidShortCode, p.minLength, p.maxLength)
+	}
+	if !aliasPattern.MatchString(code) {
+		return fmt.Errorf("%w: only letters, digits, '-' and '_' are allowed", ErrInvalidShortCode)
+	}
+	if p.IsReserved(code) {
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidShortCode, code)
+	}
+	return nil
+}
+
+// IsReserved reports whether code is on the reserved word list
+func (p *AliasPolicy) IsReserved(code string) bool {
+	_, ok := p.reserved[strings.ToLower(code)]
+	return ok
+}
+
+// Suggest returns up to maxAliasSuggestions valid codes derived from code
+// that are not yet used in the urls table
+func (p *AliasPolicy) Suggest(db *gorm.DB, code string) ([]string, error) {
+	candidates := make([]string, 0, 8)
+	add := func(candidate string) {
+		if p.Validate(candidate) == nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	for i := 1; i <= 4; i++ {
+		suffix := fmt.Sprintf("-%d", i)
+		add(truncate(code, p.maxLength-len(suffix)) + suffix)
+	}
+	for i := 0; i < 4; i++ {
+		suffix, err := randomString(base62Alphabet, 2)
+		if err != nil {
+			return nil, err
+		}
+		add(truncate(code, p.maxLength-len(suffix)) + suffix)
+	}
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	var taken []string
+	// Links in the trash still hold their codes
+	if err := db.Unscoped().Model(&models.URL{}).Where("short_code IN ?", candidates).Pluck("short_code", &taken).Error; err != nil {
+		return nil, err
+	}
+	used := make(map[string]struct{}, len(taken))
+	for _, t := range taken {
+		used[t] = struct{}{}
+	}
+
+	suggestions := make([]string, 0, maxAliasSuggestions)
+	for _, c := range candidates {
+		if _, ok := used[c]; ok {
+			continue
+		}
+		suggestions = append(suggestions, c)
+		used[c] = struct{}{}
+		if len(suggestions) == maxAliasSuggestions {
+			break
+		}
+	}
+	return suggestions, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasPolicy_Validate(t *testing.T) {
+	policy := NewAliasPolicy(4, 10, []string{"api", "Health"})
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr bool
+	}{
+		{name: "valid alias", code: "my-link_1", wantErr: false},
+		{name: "too short", code: "abc", wantErr: true},
+		{name: "too long", code: "abcdefghijk", wantErr: true},
+		{name: "slash", code: "a/b/c", wantErr: true},
+		{name: "space", code: "my link", wantErr: true},
+		{name: "reserved", code: "health", wantErr: true},
+		{name: "reserved is case insensitive", code: "HEALTH", wantErr: true},
+		{name: "empty", code: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.code)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidShortCode)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDefaultReservedAliases(t *testing.T) {
+	policy := NewAliasPolicy(1, 10, nil)
+
+	// Words used as sub-resources of /urls cannot be claimed
+	for _, code := range []string{"restore", "history", "trash", "bulk", "export", "import", "tags", "folder", "stats"} {
+		assert.ErrorIs(t, policy.Validate(code), ErrInvalidShortCode, code)
+		assert.True(t, policy.IsReserved(code), code)
+	}
+}
+
+func TestAliasPolicy_Suggest(t *testing.T) {
+	db, mock := setupTestDB(t)
+	policy := NewAliasPolicy(4, 10, nil)
+
+	mock.ExpectQuery(`SELECT "short_code" FROM "urls" WHERE short_code IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"short_code"}).AddRow("promo-1").AddRow("promo-2"))
+
+	suggestions, err := policy.Suggest(db, "promo")
+	require.NoError(t, err)
+	assert.Len(t, suggestions, maxAliasSuggestions)
+	assert.NotContains(t, suggestions, "promo-1")
+	assert.NotContains(t, suggestions, "promo-2")
+	assert.Equal(t, "promo-3", suggestions[0])
+	for _, s := range suggestions {
+		assert.NoError(t, policy.Validate(s))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/useragent"
+	"gorm.io/gorm"
+)
+
+// Supported stats intervals, as understood by Postgres date_trunc
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+const (
+	// DefaultStatsRange is used when a stats query has no start
+	DefaultStatsRange = 30 * 24 * time.Hour
+	// maxStatsBuckets bounds the length of a time series
+	maxStatsBuckets = 2000
+	// topStatsEntries is the number of entries kept per breakdown
+	topStatsEntries = 10
+)
+
+var (
+	ErrInvalidInterval   = errors.New("interval must be one of hour, day or week")
+	ErrInvalidStatsRange = errors.New("invalid stats range")
+)
+
+var intervalDurations = map[string]time.Duration{
+	IntervalHour: time.Hour,
+	IntervalDay:  24 * time.Hour,
+	IntervalWeek: 7 * 24 * time.Hour,
+}
+
+type AnalyticsServiceInterface interface {
+	GetURLStats(ctx context.Context, userID uint, urlID uint, query *models.StatsQuery) (*models.URLStats, error)
+	GetTagStats(ctx context.Context, userID uint, tagID uint, query *models.StatsQuery) (*models.TagStats, error)
+}
+
+type AnalyticsService struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsService(db *gorm.DB) *AnalyticsService {
+	return &AnalyticsService{db: db}
+}
+
+// valueCount is one row of a GROUP BY over a click_events column
+type valueCount struct {
+	Value  string
+	Clicks int64
+}
+
+func (s *AnalyticsService) GetURLStats(ctx context.Context, userID uint, urlID uint, query *models.StatsQuery) (*models.URLStats, error) {
+	if err := normalizeStatsQuery(query, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Only the owner may see the numbers
+	var owned int64
+	if err := s.db.WithContext(ctx).Model(&models.URL{}).Where("id = ? AND owner = ?", urlID, userID).Count(&owned).Error; err != nil {
+		return nil, err
+	}
+	if owned == 0 {
+		return nil, ErrURLNotFound
+	}
+
+	events := func() *gorm.DB {
+		return s.db.WithContext(ctx).Model(&models.ClickEvent{}).
+			Where("url_id = ? AND created_at >= ? AND created_at < ?", urlID, query.From, query.To)
+	}
+
+	stats := &models.URLStats{URLID: urlID}
+	if err := collectClickStats(events, query, &stats.ClickStats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetTagStats rolls up the clicks of every link carrying a tag, so that a
+// campaign can be followed as a whole
+func (s *AnalyticsService) GetTagStats(ctx context.Context, userID uint, tagID uint, query *models.StatsQuery) (*models.TagStats, error) {
+	if err := normalizeStatsQuery(query, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var tag models.Tag
+	if err := s.db.WithContext(ctx).Where("id = ? AND owner = ?", tagID, userID).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+
+	tagged := s.db.Model(&models.URLTag{}).Select("url_id").Where("tag_id = ?", tagID)
+	events := func() *gorm.DB {
+		return s.db.WithContext(ctx).Model(&models.ClickEvent{}).
+			Where("click_events.url_id IN (?) AND click_events.created_at >= ? AND click_events.created_at < ?", tagged, query.From, query.To)
+	}
+
+	stats := &models.TagStats{TagID: tag.ID, Tag: tag.Name}
+	if err := s.db.WithContext(ctx).Model(&models.URL{}).Where("id IN (?)", tagged).Count(&stats.Links).Error; err != nil {
+		return nil, err
+	}
+	if err := collectClickStats(events, query, &stats.ClickStats); err != nil {
+		return nil, err
+	}
+
+	stats.TopLinks = []models.StatCount{}
+	err := events().
+		Select("urls.short_code AS name, COUNT(*) AS clicks").
+		Joins("JOIN urls ON urls.id = click_events.url_id").
+		Group("urls.short_code").
+		Order("clicks DESC, name").
+		Limit(topStatsEntries).
+		Scan(&stats.TopLinks).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// collectClickStats fills in stats from the click events selected by events
+func collectClickStats(events func() *gorm.DB, query *models.StatsQuery, stats *models.ClickStats) error {
+	stats.From = query.From
+	stats.To = query.To
+	stats.Interval = query.Interval
+
+	// Visitors are estimated from the anonymized address and user agent
+	var totals struct {
+		TotalClicks    int64
+		UniqueVisitors int64
+	}
+	err := events().
+		Select("COUNT(*) AS total_clicks, COUNT(DISTINCT COALESCE(ip_address, '') || '|' || COALESCE(user_agent, '')) AS unique_visitors").
+		Scan(&totals).Error
+	if err != nil {
+		return err
+	}
+	stats.TotalClicks = totals.TotalClicks
+	stats.UniqueVisitors = totals.UniqueVisitors
+
+	stats.TimeSeries = []models.TimeBucket{}
+	err = events().
+		Select("date_trunc(?, created_at) AS bucket, COUNT(*) AS clicks", query.Interval).
+		Group("bucket").
+		Order("bucket").
+		Scan(&stats.TimeSeries).Error
+	if err != nil {
+		return err
+	}
+
+	// The remaining breakdowns group by the raw column in SQL, which keeps
+	// the result to one row per distinct value, and classify values in Go
+	referrers, err := groupClicks(events(), "referrer")
+	if err != nil {
+		return err
+	}
+	stats.Referrers = tally(referrers, referrerDomain)
+
+	agents, err := groupClicks(events(), "user_agent")
+	if err != nil {
+		return err
+	}
+	stats.Browsers = tally(agents, func(ua string) string { return useragent.Parse(ua).Browser })
+	stats.OperatingSystems = tally(agents, func(ua string) string { return useragent.Parse(ua).OS })
+	stats.Devices = tally(agents, func(ua string) string { return useragent.Parse(ua).Device })
+
+	languages, err := groupClicks(events(), "accept_language")
+	if err != nil {
+		return err
+	}
+	stats.Countries = tally(languages, languageCountry)
+
+	return nil
+}
+
+// normalizeStatsQuery fills in defaults and rejects ranges that are inverted
+// or would produce an unreasonably long time series
+func normalizeStatsQuery(query *models.StatsQuery, now time.Time) error {
+	if query.Interval == "" {
+		query.Interval = IntervalDay
+	}
+	step, ok := intervalDurations[query.Interval]
+	if !ok {
+		return ErrInvalidInterval
+	}
+
+	if query.To.IsZero() {
+		query.To = now
+	}
+	if query.From.IsZero() {
+		query.From = query.To.Add(-DefaultStatsRange)
+	}
+	if !query.From.Before(query.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidStatsRange)
+	}
+	if query.To.Sub(query.From)/step > maxStatsBuckets {
+		return fmt.Errorf("%w: too many buckets, use a larger interval", ErrInvalidStatsRange)
+	}
+	return nil
+}
+
+func groupClicks(db *gorm.DB, column string) ([]valueCount, error) {
+	var rows []valueCount
+	err := db.Select("COALESCE(" + column + ", '') AS value, COUNT(*) AS clicks").
+		Group("value").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// tally sums rows by the name classify assigns to each value and returns the
+// largest entries first
+func tally(rows []valueCount, classify func(string) string) []models.StatCount {
+	sums := make(map[string]int64)
+	for _, row := range rows {
+		sums[classify(row.Value)] += row.Clicks
+	}
+
+	counts := make([]models.StatCount, 0, len(sums))
+	for name, clicks := range sums {
+		counts = append(counts, models.StatCount{Name: name, Clicks: clicks})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Clicks != counts[j].Clicks {
+			return counts[i].Clicks > counts[j].Clicks
+		}
+		return counts[i].Name < counts[j].Name
+	})
+
+	if len(counts) > topStatsEntries {
+		counts = counts[:topStatsEntries]
+	}
+	return counts
+}
+
+// referrerDomain returns the host of a referrer URL, or "direct" when the
+// visit carried no usable referrer
+func referrerDomain(referrer string) string {
+	u, err := url.Parse(referrer)
+	if err != nil || u.Hostname() == "" {
+		return "direct"
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// languageCountry approximates the visitor country from the region subtag
+// of their preferred language, e.g. "en-GB,en;q=0.9" gives "GB"
+func languageCountry(acceptLanguage string) string {
+	preferred := strings.TrimSpace(strings.Split(acceptLanguage, ",")[0])
+	preferred = strings.Split(preferred, ";")[0]
+
+	parts := strings.FieldsFunc(preferred, func(r rune) bool { return r == '-' || r == '_' })
+	for _, part := range parts[min(1, len(parts)):] {
+		if len(part) == 2 {
+			return strings.ToUpper(part)
+		}
+	}
+	return useragent.Unknown
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestNormalizeStatsQuery(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   models.StatsQuery
+		want    models.StatsQuery
+		wantErr error
+	}{
+		{
+			name:  "defaults",
+			query: models.StatsQuery{},
+			want:  models.StatsQuery{From: now.Add(-DefaultStatsRange), To: now, Interval: IntervalDay},
+		},
+		{
+			name:    "unknown interval",
+			query:   models.StatsQuery{Interval: "month"},
+			wantErr: ErrInvalidInterval,
+		},
+		{
+			name:    "inverted range",
+			query:   models.StatsQuery{From: now, To: now.Add(-time.Hour)},
+			wantErr: ErrInvalidStatsRange,
+		},
+		{
+			name:    "too many hourly buckets",
+			query:   models.StatsQuery{From: now.Add(-365 * 24 * time.Hour), Interval: IntervalHour},
+			wantErr: ErrInvalidStatsRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			err := normalizeStatsQuery(&query, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, query)
+		})
+	}
+}
+
+func TestAnalyticsService_GetURLStats(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "urls" WHERE \(id = \$1 AND owner = \$2\) AND "urls"\."deleted_at" IS NULL`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total_clicks`).
+		WillReturnRows(sqlmock.NewRows([]string{"total_clicks", "unique_visitors"}).AddRow(4, 2))
+	mock.ExpectQuery(`SELECT date_trunc\(\$1, created_at\) AS bucket`).
+		WithArgs("day", 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "clicks"}).AddRow(time.Now(), 4))
+	mock.ExpectQuery(`SELECT COALESCE\(referrer, ''\) AS value`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "clicks"}).
+			AddRow("https://www.google.com/search?q=refurl", 2).
+			AddRow("https://google.com/", 1).
+			AddRow("", 1))
+	mock.ExpectQuery(`SELECT COALESCE\(user_agent, ''\) AS value`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "clicks"}).
+			AddRow("Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", 3).
+			AddRow("curl/8.0", 1))
+	mock.ExpectQuery(`SELECT COALESCE\(accept_language, ''\) AS value`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "clicks"}).
+			AddRow("en-GB,en;q=0.9", 3).
+			AddRow("de", 1))
+
+	service := NewAnalyticsService(db)
+	stats, err := service.GetURLStats(context.Background(), 1, 1, &models.StatsQuery{})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, int64(4), stats.TotalClicks)
+	assert.Equal(t, int64(2), stats.UniqueVisitors)
+	assert.Len(t, stats.TimeSeries, 1)
+	assert.Equal(t, []models.StatCount{{Name: "google.com", Clicks: 3}, {Name: "direct", Clicks: 1}}, stats.Referrers)
+	assert.Equal(t, []models.StatCount{{Name: "Safari", Clicks: 3}, {Name: "Unknown", Clicks: 1}}, stats.Browsers)
+	assert.Equal(t, []models.StatCount{{Name: "mobile", Clicks: 3}, {Name: "bot", Clicks: 1}}, stats.Devices)
+	assert.Equal(t, []models.StatCount{{Name: "GB", Clicks: 3}, {Name: "Unknown", Clicks: 1}}, stats.Countries)
+}
+
+func TestAnalyticsService_GetURLStats_NotOwner(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "urls" WHERE \(id = \$1 AND owner = \$2\) AND "urls"\."deleted_at" IS NULL`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	service := NewAnalyticsService(db)
+	_, err := service.GetURLStats(context.Background(), 2, 1, &models.StatsQuery{})
+	assert.ErrorIs(t, err, ErrURLNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnalyticsService_GetTagStats(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "tags" WHERE id = \$1 AND owner = \$2`).
+		WithArgs(3, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner", "name"}).AddRow(3, 1, "spring"))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "urls" WHERE id IN \(SELECT "url_id" FROM "url_tags" WHERE tag_id = \$1\) AND "urls"\."deleted_at" IS NULL`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total_clicks.* WHERE click_events\.url_id IN \(SELECT "url_id" FROM "url_tags" WHERE tag_id = \$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"total_clicks", "unique_visitors"}).AddRow(5, 3))
+	mock.ExpectQuery(`SELECT date_trunc`).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "clicks"}))
+	mock.ExpectQuery(`SELECT COALESCE\(referrer, ''\) AS value`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "clicks"}))
+	mock.ExpectQuery(`SELECT COALESCE\(user_agent, ''\) AS value`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "clicks"}))
+	mock.ExpectQuery(`SELECT COALESCE\(accept_language, ''\) AS value`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "clicks"}))
+	mock.ExpectQuery(`SELECT urls\.short_code AS name, COUNT\(\*\) AS clicks FROM "click_events" JOIN urls ON urls\.id = click_events\.url_id .* GROUP BY "urls"\."short_code" ORDER BY clicks DESC, name LIMIT \$4`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "clicks"}).AddRow("spr1ng", 4).AddRow("spr2ng", 1))
+
+	service := NewAnalyticsService(db)
+	stats, err := service.GetTagStats(context.Background(), 1, 3, &models.StatsQuery{})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, "spring", stats.Tag)
+	assert.Equal(t, int64(2), stats.Links)
+	assert.Equal(t, int64(5), stats.TotalClicks)
+	assert.Equal(t, []models.StatCount{{Name: "spr1ng", Clicks: 4}, {Name: "spr2ng", Clicks: 1}}, stats.TopLinks)
+}
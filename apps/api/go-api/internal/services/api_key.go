@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Scopes that can be granted to an API key. Sessions started with a password
+// are not limited by scope.
+const (
+	ScopeURLsRead  = "urls:read"
+	ScopeURLsWrite = "urls:write"
+	ScopeStatsRead = "stats:read"
+)
+
+const (
+	// APIKeyPrefix starts every API key so leaked keys are easy to spot
+	APIKeyPrefix = "rfk_"
+	// apiKeyPrefixLength is how much of a key is kept to identify it
+	apiKeyPrefixLength = 12
+	// apiKeyUsageResolution limits how often last_used_at is written
+	apiKeyUsageResolution = time.Minute
+)
+
+var validScopes = map[string]bool{
+	ScopeURLsRead:  true,
+	ScopeURLsWrite: true,
+	ScopeStatsRead: true,
+}
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid or revoked api key")
+	ErrInvalidScope   = errors.New("invalid scope")
+)
+
+type APIKeyServiceInterface interface {
+	ListAPIKeys(ctx context.Context, userID uint) ([]models.APIKeyResponse, error)
+	CreateAPIKey(ctx context.Context, userID uint, req *models.APIKeyRequest) (*models.CreatedAPIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, userID uint, id uint) error
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+type APIKeyService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{db: db, now: time.Now}
+}
+
+// ListAPIKeys returns userID's API keys, revoked ones included, newest first
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID uint) ([]models.APIKeyResponse, error) {
+	var keys []models.APIKey
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.APIKeyResponse, len(keys))
+	for i := range keys {
+		responses[i] = toAPIKeyResponse(&keys[i])
+	}
+	return responses, nil
+}
+
+// CreateAPIKey generates a key with the requested scopes. The response is the
+// only place the key is ever returned.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID uint, req *models.APIKeyRequest) (*models.CreatedAPIKeyResponse, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := &models.APIKey{
+		UserID:  userID,
+		Name:    strings.TrimSpace(req.Name),
+		Prefix:  key[:apiKeyPrefixLength],
+		KeyHash: hashToken(key),
+		Scopes:  strings.Join(scopes, ","),
+	}
+	if err := s.db.WithContext(ctx).Create(apiKey).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(apiKey),
+		Key:            key,
+	}, nil
+}
+
+// RevokeAPIKey stops the key from authenticating. The key stays listed.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID uint, id uint) error {
+	result := s.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", s.now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the active API key matching key
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey models.APIKey
+	err := s.db.WithContext(ctx).
+		Where("key_hash = ? AND revoked_at IS NULL", hashToken(key)).
+		First(&apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	// Record usage at a coarse resolution rather than writing on every call
+	now := s.now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyUsageResolution {
+		err := s.db.WithContext(ctx).Model(&models.APIKey{}).
+			Where("id = ?", apiKey.ID).
+			Update("last_used_at", now).Error
+		if err != nil {
+			return nil, err
+		}
+		apiKey.LastUsedAt = &now
+	}
+	return &apiKey, nil
+}
+
+// normalizeScopes checks scopes against the known ones and returns them
+// sorted without duplicates
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !validScopes[scope] {
+			return nil, fmt.Errorf("%w: %q is not one of %s, %s or %s",
+				ErrInvalidScope, scope, ScopeURLsRead, ScopeURLsWrite, ScopeStatsRead)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+func toAPIKeyResponse(key *models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestNormalizeScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "sorted and deduplicated", scopes: []string{"urls:write", "stats:read", "urls:write"}, want: []string{"stats:read", "urls:write"}},
+		{name: "unknown scope", scopes: []string{"urls:read", "admin"}, wantErr: true},
+		{name: "empty", scopes: []string{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeScopes(tt.scopes)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidScope)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewAPIKeyService(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_keys" \("user_id","name","prefix","key_hash","scopes","last_used_at","revoked_at","created_at"\)`).
+		WithArgs(uint(1), "CI", sqlmock.AnyArg(), sqlmock.AnyArg(), "stats:read,urls:read", nil, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectCommit()
+
+	key, err := service.CreateAPIKey(context.Background(), 1, &models.APIKeyRequest{
+		Name:   " CI ",
+		Scopes: []string{"urls:read", "stats:read"},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key.Key, APIKeyPrefix))
+	assert.Equal(t, key.Key[:apiKeyPrefixLength], key.Prefix)
+	assert.Equal(t, []string{"stats:read", "urls:read"}, key.Scopes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	key := APIKeyPrefix + "secret"
+	keyColumns := []string{"id", "user_id", "scopes", "last_used_at"}
+
+	t.Run("records usage", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewAPIKeyService(db)
+		service.now = func() time.Time { return now }
+
+		mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE key_hash = \$1 AND revoked_at IS NULL`).
+			WithArgs(hashToken(key), 1).
+			WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(3, 1, "urls:read", now.Add(-time.Hour)))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "api_keys" SET "last_used_at"=\$1 WHERE id = \$2`).
+			WithArgs(now, uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		apiKey, err := service.Authenticate(context.Background(), key)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), apiKey.UserID)
+		assert.True(t, apiKey.HasScope(ScopeURLsRead))
+		assert.False(t, apiKey.HasScope(ScopeURLsWrite))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("recently used", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewAPIKeyService(db)
+		service.now = func() time.Time { return now }
+
+		mock.ExpectQuery(`SELECT \* FROM "api_keys"`).
+			WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(3, 1, "urls:read", now.Add(-time.Second)))
+
+		_, err := service.Authenticate(context.Background(), key)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown or revoked", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewAPIKeyService(db)
+
+		mock.ExpectQuery(`SELECT \* FROM "api_keys"`).
+			WillReturnRows(sqlmock.NewRows(keyColumns))
+
+		_, err := service.Authenticate(context.Background(), key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not an api key", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewAPIKeyService(db)
+
+		_, err := service.Authenticate(context.Background(), "eyJhbGciOi")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAPIKeyService_RevokeAPIKey(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewAPIKeyService(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_keys" SET "revoked_at"=\$1 WHERE id = \$2 AND user_id = \$3 AND revoked_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), uint(3), uint(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := service.RevokeAPIKey(context.Background(), 2, 3)
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
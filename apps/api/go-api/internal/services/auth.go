@@ -2,32 +2,114 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+const (
+	// DefaultAccessTokenTTL is used when no access token lifetime is configured
+	DefaultAccessTokenTTL = 15 * time.Minute
+	// DefaultRefreshTokenTTL is used when no refresh token lifetime is configured
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when a refresh token is presented
+	// after it was rotated, which revokes every token in its family
+	ErrRefreshTokenReused = fmt.Errorf("%w: token was already used", ErrInvalidRefreshToken)
+	// ErrTokenNotRevocable is returned when logging out a token issued
+	// without an ID; only logging out everywhere can revoke it
+	ErrTokenNotRevocable = errors.New("token cannot be revoked individually")
+	// ErrAccountDisabled is returned when a disabled user logs in or refreshes
+	ErrAccountDisabled = errors.New("account is disabled")
+)
+
 type AuthService struct {
-	db     *gorm.DB
-	secret string
+	db          *gorm.DB
+	secret      string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	revocations *RevocationList
+	verifier    *EmailVerifier
+	now         func() time.Time
 }
 
 type AuthServiceInterface interface {
 	Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthResponse, error)
 	Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error)
+	Refresh(ctx context.Context, req *models.RefreshRequest) (*models.AuthResponse, error)
+	Logout(ctx context.Context, claims *AccessClaims, req *models.LogoutRequest) error
+	LogoutEverywhere(ctx context.Context, userID uint) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, userID uint) error
+}
+
+// AuthServiceOption configures optional AuthService settings
+type AuthServiceOption func(*AuthService)
+
+// WithAccessTokenTTL sets how long issued access tokens are valid
+func WithAccessTokenTTL(ttl time.Duration) AuthServiceOption {
+	return func(s *AuthService) {
+		if ttl > 0 {
+			s.accessTTL = ttl
+		}
+	}
 }
 
-func NewAuthService(db *gorm.DB, secret string) *AuthService {
-	return &AuthService{
-		db:     db,
-		secret: secret,
+// WithRefreshTokenTTL sets how long a refresh token can be exchanged
+func WithRefreshTokenTTL(ttl time.Duration) AuthServiceOption {
+	return func(s *AuthService) {
+		if ttl > 0 {
+			s.refreshTTL = ttl
+		}
 	}
 }
 
+// WithRevocationList sets the list that logouts are recorded in, which
+// should be the one middleware.Auth consults
+func WithRevocationList(revocations *RevocationList) AuthServiceOption {
+	return func(s *AuthService) {
+		s.revocations = revocations
+	}
+}
+
+// WithEmailVerifier makes new accounts verify their email address through
+// verifier. Without one, accounts are verified as soon as they register.
+func WithEmailVerifier(verifier *EmailVerifier) AuthServiceOption {
+	return func(s *AuthService) {
+		s.verifier = verifier
+	}
+}
+
+func NewAuthService(db *gorm.DB, secret string, opts ...AuthServiceOption) *AuthService {
+	s := &AuthService{
+		db:         db,
+		secret:     secret,
+		accessTTL:  DefaultAccessTokenTTL,
+		refreshTTL: DefaultRefreshTokenTTL,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.revocations == nil {
+		s.revocations = NewRevocationList(db, DefaultRevocationCacheTTL, DefaultRevocationPruneInterval)
+	}
+	return s
+}
+
 func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthResponse, error) {
 	// Check if user already exists
 	var existingUser models.User
@@ -42,34 +124,28 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 	}
 
 	// Create user
+	now := s.now()
 	user := &models.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: string(hashedPassword),
 	}
+	if s.verifier == nil {
+		user.EmailVerifiedAt = &now
+	}
 
 	if err := s.db.Create(user).Error; err != nil {
 		return nil, err
 	}
 
-	// Generate token
-	token, err := s.generateToken(user)
-	if err != nil {
-		return nil, err
+	// The account works without the email; the user can ask for another one
+	if s.verifier != nil {
+		if err := s.verifier.Send(ctx, user, now); err != nil {
+			logger.Error("Failed to send verification email to user %d: %v", user.ID, err)
+		}
 	}
 
-	return &models.AuthResponse{
-		Token: token,
-		User: struct {
-			ID    uint   `json:"id"`
-			Name  string `json:"name"`
-			Email string `json:"email"`
-		}{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-		},
-	}, nil
+	return s.issueTokens(s.db.WithContext(ctx), user, "")
 }
 
 func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
@@ -86,39 +162,236 @@ func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
 		return nil, errors.New("invalid credentials")
 	}
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	return s.issueTokens(s.db.WithContext(ctx), &user, "")
+}
+
+// Refresh exchanges a refresh token for a new access token and the next
+// refresh token of the same family. Each refresh token can be exchanged once;
+// presenting it again means it has leaked, so the whole family is revoked and
+// its holder has to log in again.
+func (s *AuthService) Refresh(ctx context.Context, req *models.RefreshRequest) (*models.AuthResponse, error) {
+	now := s.now()
+
+	var token models.RefreshToken
+	if err := s.db.WithContext(ctx).Where("token_hash = ?", hashToken(req.RefreshToken)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if token.RevokedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var resp *models.AuthResponse
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Claiming the token with a conditional update lets only one of two
+		// concurrent refreshes through; the other counts as reuse
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		var user models.User
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if user.DisabledAt != nil {
+			return ErrAccountDisabled
+		}
+
+		var err error
+		resp, err = s.issueTokens(tx, &user, token.FamilyID)
+		return err
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if revokeErr := s.revokeFamily(ctx, token.FamilyID, now); revokeErr != nil {
+			return nil, revokeErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Logout revokes the access token with claims and, if given, the family of
+// the refresh token issued alongside it
+func (s *AuthService) Logout(ctx context.Context, claims *AccessClaims, req *models.LogoutRequest) error {
+	if claims.ID == "" {
+		return ErrTokenNotRevocable
+	}
+	if err := s.revocations.RevokeToken(ctx, claims); err != nil {
+		return err
+	}
+	if req.RefreshToken == "" {
+		return nil
+	}
+
+	var token models.RefreshToken
+	err := s.db.WithContext(ctx).
+		Where("token_hash = ? AND user_id = ?", hashToken(req.RefreshToken), claims.UserID).
+		First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.revokeFamily(ctx, token.FamilyID, s.now())
+}
 
-	// Generate token
-	token, err := s.generateToken(&user)
+// LogoutEverywhere revokes every access and refresh token issued to userID
+func (s *AuthService) LogoutEverywhere(ctx context.Context, userID uint) error {
+	now := s.now()
+	if err := s.revocations.RevokeUser(ctx, userID, now); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// VerifyEmail confirms the email address a verification token was sent to
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	if s.verifier == nil {
+		return ErrInvalidVerificationToken
+	}
+	userID, ok := s.verifier.UserID(token)
+	if !ok {
+		return ErrInvalidVerificationToken
+	}
+
+	var user models.User
+	err := s.db.WithContext(ctx).Select("id", "email", "email_verified_at").Where("id = ?", userID).Take(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		return err
+	}
+	if !s.verifier.Verify(token, user.ID, user.Email, s.now()) {
+		return ErrInvalidVerificationToken
+	}
+	// Following the link twice is harmless
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return s.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", user.ID).
+		Update("email_verified_at", s.now()).Error
+}
+
+// ResendVerification emails userID a new verification link
+func (s *AuthService) ResendVerification(ctx context.Context, userID uint) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ?", userID).Take(&user).Error; err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil || s.verifier == nil {
+		return ErrEmailAlreadyVerified
+	}
+	return s.verifier.Send(ctx, &user, s.now())
+}
+
+// revokeFamily revokes every token descended from the same login
+func (s *AuthService) revokeFamily(ctx context.Context, familyID string, now time.Time) error {
+	return s.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+// issueTokens signs an access token for user and stores a new refresh token
+// in familyID, or in a new family when familyID is empty
+func (s *AuthService) issueTokens(db *gorm.DB, user *models.User, familyID string) (*models.AuthResponse, error) {
+	token, err := s.generateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if familyID == "" {
+		if familyID, err = randomHex(16); err != nil {
+			return nil, err
+		}
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(secret)
+
+	err = db.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: s.now().Add(s.refreshTTL),
+	}).Error
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.AuthResponse{
-		Token: token,
-		User: struct {
-			ID    uint   `json:"id"`
-			Name  string `json:"name"`
-			Email string `json:"email"`
-		}{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-		},
-	}, nil
+	resp := &models.AuthResponse{
+		Token:        token,
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+		RefreshToken: refreshToken,
+	}
+	resp.User.ID = user.ID
+	resp.User.Name = user.Name
+	resp.User.Email = user.Email
+	return resp, nil
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns the form in which a refresh token is stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func (s *AuthService) generateToken(user *models.User) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now()
 	claims := jwt.MapClaims{
 		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
-		"iat": time.Now().Unix(),
+		"exp": now.Add(s.accessTTL).Unix(),
+		"iat": now.Unix(),
+		// iat has whole seconds only; revocation needs the exact issue time
+		"iat_us": now.UnixMicro(),
+		"jti":    jti,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.secret))
 }
 
-func (s *AuthService) ValidateToken(tokenString string) (uint, error) {
+// ValidateToken checks the signature and expiry of an access token and
+// returns its claims. Whether it was revoked is up to the RevocationList.
+func (s *AuthService) ValidateToken(tokenString string) (*AccessClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -127,13 +400,27 @@ func (s *AuthService) ValidateToken(tokenString string) (uint, error) {
 	})
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := uint(claims["sub"].(float64))
-		return userID, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return nil, errors.New("invalid token")
 	}
 
-	return 0, errors.New("invalid token")
+	result := &AccessClaims{UserID: uint(sub)}
+	result.ID, _ = claims["jti"].(string)
+	if iatUS, ok := claims["iat_us"].(float64); ok {
+		result.IssuedAt = time.UnixMicro(int64(iatUS))
+	} else if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		result.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		result.ExpiresAt = exp.Time
+	}
+	return result, nil
 }
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestAuthService_Refresh(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tokenColumns := []string{"id", "user_id", "family_id", "token_hash", "expires_at", "used_at", "revoked_at"}
+	family := "0123456789abcdef0123456789abcdef"
+
+	newService := func(t *testing.T) (*AuthService, sqlmock.Sqlmock) {
+		db, mock := setupTestDB(t)
+		service := NewAuthService(db, "secret", WithAccessTokenTTL(10*time.Minute), WithRefreshTokenTTL(time.Hour))
+		service.now = func() time.Time { return now }
+		mock.MatchExpectationsInOrder(true)
+		return service, mock
+	}
+
+	t.Run("rotated", func(t *testing.T) {
+		service, mock := newService(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "refresh_tokens" WHERE token_hash = \$1`).
+			WithArgs(hashToken("r1"), 1).
+			WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(5, 1, family, hashToken("r1"), now.Add(time.Hour), nil, nil))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "refresh_tokens" SET "used_at"=\$1 WHERE id = \$2 AND used_at IS NULL`).
+			WithArgs(now, uint(5)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"\."id" = \$1`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).AddRow(1, "Test User", "test@example.com"))
+		mock.ExpectQuery(`INSERT INTO "refresh_tokens"`).
+			WithArgs(uint(1), family, sqlmock.AnyArg(), now.Add(time.Hour), nil, nil, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+		mock.ExpectCommit()
+
+		resp, err := service.Refresh(context.Background(), &models.RefreshRequest{RefreshToken: "r1"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.Token)
+		assert.NotEqual(t, "r1", resp.RefreshToken)
+		assert.Equal(t, int64(600), resp.ExpiresIn)
+		assert.Equal(t, uint(1), resp.User.ID)
+
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (interface{}, error) {
+			return []byte("secret"), nil
+		}, jwt.WithoutClaimsValidation())
+		require.NoError(t, err)
+		assert.Equal(t, float64(now.Add(10*time.Minute).Unix()), claims["exp"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reused token revokes family", func(t *testing.T) {
+		service, mock := newService(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "refresh_tokens" WHERE token_hash = \$1`).
+			WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(5, 1, family, hashToken("r1"), now.Add(time.Hour), now.Add(-time.Minute), nil))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "refresh_tokens" SET "used_at"=\$1 WHERE id = \$2 AND used_at IS NULL`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "refresh_tokens" SET "revoked_at"=\$1 WHERE family_id = \$2 AND revoked_at IS NULL`).
+			WithArgs(now, family).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		_, err := service.Refresh(context.Background(), &models.RefreshRequest{RefreshToken: "r1"})
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		service, mock := newService(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "refresh_tokens" WHERE token_hash = \$1`).
+			WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(5, 1, family, hashToken("r1"), now, nil, nil))
+
+		_, err := service.Refresh(context.Background(), &models.RefreshRequest{RefreshToken: "r1"})
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		service, mock := newService(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "refresh_tokens" WHERE token_hash = \$1`).
+			WillReturnRows(sqlmock.NewRows(tokenColumns))
+
+		_, err := service.Refresh(context.Background(), &models.RefreshRequest{RefreshToken: "nope"})
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/validator"
+)
+
+const (
+	// MaxBulkItems bounds the number of operations in one bulk request
+	MaxBulkItems = 1000
+	// bulkBatchSize is how many operations run between cancellation checks,
+	// and how many links one bulk delete query covers
+	bulkBatchSize = 100
+)
+
+var ErrTooManyBulkItems = fmt.Errorf("a bulk request may contain at most %d operations", MaxBulkItems)
+
+// BulkCreateURLs creates each link in reqs independently and reports the
+// outcome of each. It stops early only when ctx is done.
+func (s *URLService) BulkCreateURLs(ctx context.Context, userID uint, reqs []models.CreateURLRequest) ([]models.BulkResult, error) {
+	if len(reqs) > MaxBulkItems {
+		return nil, ErrTooManyBulkItems
+	}
+
+	results := make([]models.BulkResult, len(reqs))
+	for start := 0; start < len(reqs); start += bulkBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for i := start; i < min(start+bulkBatchSize, len(reqs)); i++ {
+			results[i] = models.BulkResult{Index: i}
+			if err := validator.Struct(&reqs[i]); err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			url, err := s.CreateURL(ctx, userID, &reqs[i])
+			if err != nil {
+				results[i].Error = bulkErrorReason(err)
+				continue
+			}
+			results[i].Success = true
+			results[i].ID = url.ID
+			results[i].URL = url
+		}
+	}
+	return results, nil
+}
+
+// BulkUpdateURLs applies each item as a merge patch, like PatchURL
+func (s *URLService) BulkUpdateURLs(ctx context.Context, userID uint, items []models.BulkUpdateItem) ([]models.BulkResult, error) {
+	if len(items) > MaxBulkItems {
+		return nil, ErrTooManyBulkItems
+	}
+
+	results := make([]models.BulkResult, len(items))
+	for start := 0; start < len(items); start += bulkBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for i := start; i < min(start+bulkBatchSize, len(items)); i++ {
+			item := items[i]
+			results[i] = models.BulkResult{Index: i, ID: item.ID}
+			url, err := s.PatchURL(ctx, userID, item.ID, item.Changes, item.Version)
+			if err != nil {
+				results[i].Error = bulkErrorReason(err)
+				continue
+			}
+			results[i].Success = true
+			results[i].URL = url
+		}
+	}
+	return results, nil
+}
+
+// BulkDeleteURLs moves the links with the given IDs to the trash, one query
+// per batch, reporting IDs userID does not own as not found
+func (s *URLService) BulkDeleteURLs(ctx context.Context, userID uint, ids []uint) ([]models.BulkResult, error) {
+	if len(ids) > MaxBulkItems {
+		return nil, ErrTooManyBulkItems
+	}
+
+	results := make([]models.BulkResult, len(ids))
+	for start := 0; start < len(ids); start += bulkBatchSize {
+		batch := ids[start:min(start+bulkBatchSize, len(ids))]
+
+		var owned []uint
+		err := s.db.WithContext(ctx).Model(&models.URL{}).
+			Where("id IN ? AND owner = ?", batch, userID).
+			Pluck("id", &owned).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(owned) > 0 {
+			if err := s.db.WithContext(ctx).Where("id IN ?", owned).Delete(&models.URL{}).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		deleted := make(map[uint]bool, len(owned))
+		for _, id := range owned {
+			deleted[id] = true
+			s.invalidate(id)
+		}
+		for i, id := range batch {
+			result := models.BulkResult{Index: start + i, ID: id, Success: deleted[id]}
+			if !result.Success {
+				result.Error = ErrURLNotFound.Error()
+			}
+			results[start+i] = result
+		}
+	}
+	return results, nil
+}
+
+// bulkErrorReason is the reason reported for a failed bulk operation. Errors
+// that are not the caller's to fix are logged and reported generically.
+func bulkErrorReason(err error) string {
+	if isRejection(err) {
+		return err.Error()
+	}
+	logger.Error("Bulk operation failed: %v", err)
+	return "internal error"
+}
+
+// isRejection reports whether err rejects the caller's input, as opposed to
+// a failure on our side
+func isRejection(err error) bool {
+	var conflict *ShortCodeConflictError
+	var fields validator.ValidationErrors
+	return errors.As(err, &conflict) || errors.As(err, &fields) ||
+		errors.Is(err, ErrURLNotFound) || errors.Is(err, ErrInvalidShortCode) ||
+		errors.Is(err, ErrInvalidExpiry) || errors.Is(err, ErrInvalidRedirectStatus) ||
+		errors.Is(err, ErrInvalidDestination) || errors.Is(err, ErrVersionMismatch) ||
+		errors.Is(err, ErrInvalidPatch) || errors.Is(err, ErrShortCodeExhausted) ||
+		errors.Is(err, ErrEmailNotVerified)
+}
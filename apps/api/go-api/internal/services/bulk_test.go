@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestURLService_BulkCreateURLs(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewURLService(db)
+
+	expectDestinationAllowed(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "urls"`).
+		WithArgs("https://example.com", "abc123", "Example", uint(1), 0, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, "", false, "", 0, int64(1), nil, nil, nil, "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectCommit()
+
+	results, err := service.BulkCreateURLs(context.Background(), 1, []models.CreateURLRequest{
+		{OriginalURL: "not a url"},
+		{OriginalURL: "https://example.com", Title: "Example", ShortCode: "abc123"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.False(t, results[0].Success)
+	assert.Contains(t, results[0].Error, "original_url")
+	assert.Equal(t, models.BulkResult{Index: 1, Success: true, ID: 5, URL: results[1].URL}, results[1])
+	assert.Equal(t, "abc123", results[1].URL.ShortCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestURLService_BulkDeleteURLs(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewURLService(db)
+
+	mock.ExpectQuery(`SELECT "id" FROM "urls" WHERE \(id IN \(\$1,\$2,\$3\) AND owner = \$4\) AND "urls"\."deleted_at" IS NULL`).
+		WithArgs(1, 2, 3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(3))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "urls" SET "deleted_at"=\$1 WHERE id IN \(\$2,\$3\) AND "urls"\."deleted_at" IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	results, err := service.BulkDeleteURLs(context.Background(), 1, []uint{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, []models.BulkResult{
+		{Index: 0, Success: true, ID: 1},
+		{Index: 1, ID: 2, Error: ErrURLNotFound.Error()},
+		{Index: 2, Success: true, ID: 3},
+	}, results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestURLService_Bulk_TooManyItems(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewURLService(db)
+
+	_, err := service.BulkCreateURLs(context.Background(), 1, make([]models.CreateURLRequest, MaxBulkItems+1))
+	assert.ErrorIs(t, err, ErrTooManyBulkItems)
+	_, err = service.BulkDeleteURLs(context.Background(), 1, make([]uint, MaxBulkItems+1))
+	assert.ErrorIs(t, err, ErrTooManyBulkItems)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+const (
+	DefaultRedirectCacheSize = 10000
+	DefaultRedirectCacheTTL  = 5 * time.Minute
+)
+
+// RedirectCacheStats reports redirect cache effectiveness
+type RedirectCacheStats struct {
+	Size      int   `json:"size"`
+	Capacity  int   `json:"capacity"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+type redirectCacheEntry struct {
+	shortCode string
+	url       models.URLResponse
+	expiresAt time.Time
+}
+
+// RedirectCache is a size-bounded LRU cache of short code lookups whose
+// entries also expire after a TTL
+type RedirectCache struct {
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	byID    map[uint]string
+
+	// generation counts invalidations; invalidated holds the generation at
+	// which each ID was last invalidated, and floor the generation at which
+	// that map was last cleared
+	generation  uint64
+	invalidated map[uint]uint64
+	floor       uint64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+func NewRedirectCache(capacity int, ttl time.Duration) *RedirectCache {
+	if capacity <= 0 {
+		capacity = DefaultRedirectCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultRedirectCacheTTL
+	}
+
+	return &RedirectCache{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		byID:     make(map[uint]string),
+
+		invalidated: make(map[uint]uint64),
+	}
+}
+
+// Get returns a copy of the cached URL for shortCode
+func (c *RedirectCache) Get(shortCode string) (*models.URLResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[shortCode]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	entry := elem.Value.(*redirectCacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
+	url := entry.url
+	return &url, true
+}
+
+// Set caches url under its short code, evicting the least recently used
+// entry when the cache is full
+func (c *RedirectCache) Set(url *models.URLResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(url)
+}
+
+// Generation returns a token to pass to SetIfCurrent. Take it before reading
+// the URL from the database.
+func (c *RedirectCache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// SetIfCurrent caches url unless its ID was invalidated after generation was
+// taken, in which case url may have been read before the change that
+// invalidated it
+func (c *RedirectCache) SetIfCurrent(url *models.URLResponse, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation < c.floor || c.invalidated[url.ID] > generation {
+		return
+	}
+	c.set(url)
+}
+
+func (c *RedirectCache) set(url *models.URLResponse) {
+	if elem, ok := c.entries[url.ShortCode]; ok {
+		c.removeElement(elem)
+	}
+	if code, ok := c.byID[url.ID]; ok {
+		c.removeElement(c.entries[code])
+	}
+
+	elem := c.order.PushFront(&redirectCacheEntry{
+		shortCode: url.ShortCode,
+		url:       *url,
+		expiresAt: c.now().Add(c.ttl),
+	})
+	c.entries[url.ShortCode] = elem
+	c.byID[url.ID] = url.ShortCode
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// Invalidate drops the entry for the URL with the given ID, whatever short
+// code it was cached under
+func (c *RedirectCache) Invalidate(id uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.invalidated[id] = c.generation
+	if len(c.invalidated) > c.capacity {
+		// Forget the per-ID generations and refuse every older token instead
+		c.invalidated = make(map[uint]uint64)
+		c.floor = c.generation
+	}
+
+	if code, ok := c.byID[id]; ok {
+		c.removeElement(c.entries[code])
+	}
+}
+
+// Stats returns the current cache metrics
+func (c *RedirectCache) Stats() RedirectCacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return RedirectCacheStats{
+		Size:      size,
+		Capacity:  c.capacity,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+func (c *RedirectCache) removeElement(elem *list.Element) {
+	if elem == nil {
+		return
+	}
+	entry := c.order.Remove(elem).(*redirectCacheEntry)
+	delete(c.entries, entry.shortCode)
+	if c.byID[entry.url.ID] == entry.shortCode {
+		delete(c.byID, entry.url.ID)
+	}
+}
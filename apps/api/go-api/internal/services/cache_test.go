@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestRedirectCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewRedirectCache(2, time.Minute)
+
+	cache.Set(&models.URLResponse{ID: 1, ShortCode: "one", OriginalURL: "https://one.example"})
+	cache.Set(&models.URLResponse{ID: 2, ShortCode: "two", OriginalURL: "https://two.example"})
+
+	_, ok := cache.Get("one")
+	assert.True(t, ok)
+
+	cache.Set(&models.URLResponse{ID: 3, ShortCode: "three", OriginalURL: "https://three.example"})
+
+	_, ok = cache.Get("two")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	got, ok := cache.Get("one")
+	require.True(t, ok)
+	assert.Equal(t, "https://one.example", got.OriginalURL)
+
+	assert.Equal(t, RedirectCacheStats{Size: 2, Capacity: 2, Hits: 2, Misses: 1, Evictions: 1}, cache.Stats())
+}
+
+func TestRedirectCache_ExpiresEntries(t *testing.T) {
+	now := time.Now()
+	cache := NewRedirectCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Set(&models.URLResponse{ID: 1, ShortCode: "one"})
+	_, ok := cache.Get("one")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.Get("one")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Stats().Size)
+}
+
+func TestRedirectCache_InvalidateByID(t *testing.T) {
+	cache := NewRedirectCache(10, time.Minute)
+
+	cache.Set(&models.URLResponse{ID: 1, ShortCode: "old"})
+	cache.Set(&models.URLResponse{ID: 1, ShortCode: "new"})
+	_, ok := cache.Get("old")
+	assert.False(t, ok, "re-caching an ID under a new code drops the old code")
+
+	cache.Invalidate(1)
+	_, ok = cache.Get("new")
+	assert.False(t, ok)
+}
+
+func TestRedirectCache_SetIfCurrent(t *testing.T) {
+	cache := NewRedirectCache(2, time.Minute)
+
+	stale := cache.Generation()
+	cache.Invalidate(1)
+	cache.SetIfCurrent(&models.URLResponse{ID: 1, ShortCode: "one"}, stale)
+	_, ok := cache.Get("one")
+	assert.False(t, ok, "a read from before the invalidation is not cached")
+
+	cache.SetIfCurrent(&models.URLResponse{ID: 2, ShortCode: "two"}, stale)
+	_, ok = cache.Get("two")
+	assert.True(t, ok, "other IDs are unaffected")
+
+	current := cache.Generation()
+	cache.SetIfCurrent(&models.URLResponse{ID: 1, ShortCode: "one"}, current)
+	_, ok = cache.Get("one")
+	assert.True(t, ok)
+
+	// Once more IDs are invalidated than the cache holds, every older token
+	// is refused
+	cache.Invalidate(2)
+	cache.Invalidate(3)
+	cache.SetIfCurrent(&models.URLResponse{ID: 4, ShortCode: "four"}, current)
+	_, ok = cache.Get("four")
+	assert.False(t, ok)
+}
+
+func TestURLService_GetURLByShortCode_RacingInvalidate(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	rows := sqlmock.NewRows([]string{"id", "original_url", "title", "short_code", "owner", "clicks", "created_at", "clicks_at"}).
+		AddRow(1, "https://old.example", "Example", "abc123", 1, 0, time.Now(), time.Now())
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE short_code = \$1`).
+		WithArgs("abc123", 1).
+		WillReturnRows(rows)
+
+	cache := NewRedirectCache(10, time.Minute)
+	service := NewURLService(db, WithRedirectCache(cache))
+
+	// An update commits and invalidates right after the old row was read
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:update", func(*gorm.DB) {
+		service.invalidate(1)
+	}))
+
+	got, err := service.GetURLByShortCode(context.Background(), "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "https://old.example", got.OriginalURL)
+
+	_, ok := cache.Get("abc123")
+	assert.False(t, ok, "the row read before the update is not cached")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestURLService_GetURLByShortCode_UsesCache(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	rows := sqlmock.NewRows([]string{"id", "original_url", "title", "short_code", "owner", "clicks", "created_at", "clicks_at"}).
+		AddRow(1, "https://example.com", "Example", "abc123", 1, 0, time.Now(), time.Now())
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE short_code = \$1`).
+		WithArgs("abc123", 1).
+		WillReturnRows(rows)
+
+	cache := NewRedirectCache(10, time.Minute)
+	service := NewURLService(db, WithRedirectCache(cache))
+
+	for i := 0; i < 3; i++ {
+		got, err := service.GetURLByShortCode(context.Background(), "abc123")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", got.OriginalURL)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int64(2), cache.Stats().Hits)
+	assert.Equal(t, int64(1), cache.Stats().Misses)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "urls" SET "deleted_at"=\$1 WHERE \(id = \$2 AND owner = \$3\) AND "urls"\."deleted_at" IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, service.DeleteURL(context.Background(), 1, 1))
+	_, ok := cache.Get("abc123")
+	assert.False(t, ok, "deleting a URL invalidates its cached redirect")
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrClickLimitReached = errors.New("click limit reached")
+
+// RecordClick stores a click event and bumps the aggregate counter of its URL.
+// With a ClickRecorder configured the click is queued and written later in a
+// batch; otherwise it is written immediately.
+func (s *URLService) RecordClick(ctx context.Context, click *models.ClickEvent) error {
+	prepareClick(click)
+
+	if s.clicks != nil {
+		s.clicks.Enqueue(click)
+		return nil
+	}
+	return saveClicks(s.db.WithContext(ctx), []*models.ClickEvent{click})
+}
+
+// ClaimClick counts click against the click limit of its URL before the
+// visitor is let through, returning ErrClickLimitReached once the limit is
+// used up. Unlike RecordClick it always writes synchronously: queued clicks
+// are not counted yet, so they could not hold the limit.
+func (s *URLService) ClaimClick(ctx context.Context, click *models.ClickEvent) error {
+	prepareClick(click)
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.URL{}).
+			Where("id = ? AND (max_clicks IS NULL OR clicks < max_clicks)", click.URLID).
+			Updates(map[string]interface{}{
+				"clicks":    gorm.Expr("clicks + 1"),
+				"clicks_at": gorm.Expr("GREATEST(clicks_at, ?)", click.CreatedAt),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrClickLimitReached
+		}
+		return tx.Create(click).Error
+	})
+}
+
+// prepareClick timestamps click and anonymizes its address
+func prepareClick(click *models.ClickEvent) {
+	if click.CreatedAt.IsZero() {
+		click.CreatedAt = time.Now()
+	}
+	click.IPAddress = AnonymizeIP(click.IPAddress)
+}
+
+// saveClicks inserts clicks and increments the counters of their URLs in one
+// transaction, so clicks always matches the number of events. Counters are
+// bumped atomically in SQL so concurrent writers never lose increments.
+func saveClicks(db *gorm.DB, clicks []*models.ClickEvent) error {
+	type urlClicks struct {
+		count int64
+		last  time.Time
+	}
+
+	totals := make(map[uint]*urlClicks)
+	for _, click := range clicks {
+		t, ok := totals[click.URLID]
+		if !ok {
+			t = &urlClicks{}
+			totals[click.URLID] = t
+		}
+		t.count++
+		if click.CreatedAt.After(t.last) {
+			t.last = click.CreatedAt
+		}
+	}
+
+	// Update in a stable order so concurrent batches cannot deadlock
+	ids := make([]uint, 0, len(totals))
+	for id := range totals {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(clicks, len(clicks)).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			t := totals[id]
+			err := tx.Model(&models.URL{}).
+				Where("id = ?", id).
+				Updates(map[string]interface{}{
+					"clicks":    gorm.Expr("clicks + ?", t.count),
+					"clicks_at": gorm.Expr("GREATEST(clicks_at, ?)", t.last),
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AnonymizeIP drops the host part of an address: the last octet of IPv4 and
+// everything past the /48 prefix of IPv6. Unparseable input yields "".
+func AnonymizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
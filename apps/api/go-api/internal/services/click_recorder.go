@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultClickQueueSize     = 10000
+	DefaultClickBatchSize     = 100
+	DefaultClickFlushInterval = time.Second
+)
+
+// ClickRecorderStats reports the state of the click queue
+type ClickRecorderStats struct {
+	Queued  int   `json:"queued"`
+	Flushed int64 `json:"flushed"`
+	Dropped int64 `json:"dropped"`
+	Failed  int64 `json:"failed"`
+}
+
+// ClickRecorder buffers click events in a bounded queue and writes them in
+// batches from a background worker, keeping writes off the redirect path
+type ClickRecorder struct {
+	db            *gorm.DB
+	queue         chan *models.ClickEvent
+	batchSize     int
+	flushInterval time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+
+	flushed atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+func NewClickRecorder(db *gorm.DB, queueSize, batchSize int, flushInterval time.Duration) *ClickRecorder {
+	if queueSize <= 0 {
+		queueSize = DefaultClickQueueSize
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultClickBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = DefaultClickFlushInterval
+	}
+
+	return &ClickRecorder{
+		db:            db,
+		queue:         make(chan *models.ClickEvent, queueSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+	}
+}
+
+// Start launches the background flush worker
+func (r *ClickRecorder) Start() {
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Enqueue queues a click without blocking. When the queue is full the click
+// is dropped and counted, and false is returned.
+func (r *ClickRecorder) Enqueue(click *models.ClickEvent) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		r.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case r.queue <- click:
+		return true
+	default:
+		r.dropped.Add(1)
+		return false
+	}
+}
+
+// Close stops accepting clicks and waits for the worker to flush everything
+// still queued, or for ctx to be done
+func (r *ClickRecorder) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current queue metrics
+func (r *ClickRecorder) Stats() ClickRecorderStats {
+	return ClickRecorderStats{
+		Queued:  len(r.queue),
+		Flushed: r.flushed.Load(),
+		Dropped: r.dropped.Load(),
+		Failed:  r.failed.Load(),
+	}
+}
+
+func (r *ClickRecorder) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.ClickEvent, 0, r.batchSize)
+	for {
+		select {
+		case click, ok := <-r.queue:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, click)
+			if len(batch) >= r.batchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (r *ClickRecorder) flush(batch []*models.ClickEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	err := saveClicks(r.db, batch)
+	if err == nil {
+		r.flushed.Add(int64(len(batch)))
+		return
+	}
+
+	// One bad row, such as a click on a link purged since, fails the whole
+	// batch. Retry each link's clicks on their own so the others are kept.
+	groups := groupClicksByURL(batch)
+	if len(groups) == 1 {
+		r.failed.Add(int64(len(batch)))
+		logger.Error("Failed to flush %d click events: %v", len(batch), err)
+		return
+	}
+	for _, group := range groups {
+		if err := saveClicks(r.db, group); err != nil {
+			r.failed.Add(int64(len(group)))
+			logger.Error("Failed to flush %d click events for URL %d: %v", len(group), group[0].URLID, err)
+			continue
+		}
+		r.flushed.Add(int64(len(group)))
+	}
+}
+
+// groupClicksByURL splits clicks by URL, ordered by URL ID
+func groupClicksByURL(clicks []*models.ClickEvent) [][]*models.ClickEvent {
+	byURL := make(map[uint][]*models.ClickEvent)
+	var ids []uint
+	for _, click := range clicks {
+		if _, ok := byURL[click.URLID]; !ok {
+			ids = append(ids, click.URLID)
+		}
+		byURL[click.URLID] = append(byURL[click.URLID], click)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	groups := make([][]*models.ClickEvent, len(ids))
+	for i, id := range ids {
+		groups[i] = byURL[id]
+	}
+	return groups
+}
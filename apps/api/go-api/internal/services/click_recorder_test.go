@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestClickRecorder_FlushesBatchOnClose(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "click_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+	mock.ExpectExec(`UPDATE "urls" SET "clicks"=clicks \+ \$1,"clicks_at"=GREATEST\(clicks_at, \$2\) WHERE id = \$3`).
+		WithArgs(int64(2), sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "urls" SET "clicks"=clicks \+ \$1,"clicks_at"=GREATEST\(clicks_at, \$2\) WHERE id = \$3`).
+		WithArgs(int64(1), sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	recorder := NewClickRecorder(db, 10, 10, time.Hour)
+	recorder.Start()
+
+	now := time.Now()
+	assert.True(t, recorder.Enqueue(&models.ClickEvent{URLID: 2, ShortCode: "two", CreatedAt: now}))
+	assert.True(t, recorder.Enqueue(&models.ClickEvent{URLID: 1, ShortCode: "one", CreatedAt: now}))
+	assert.True(t, recorder.Enqueue(&models.ClickEvent{URLID: 1, ShortCode: "one", CreatedAt: now}))
+
+	require.NoError(t, recorder.Close(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, ClickRecorderStats{Flushed: 3}, recorder.Stats())
+}
+
+func TestClickRecorder_DropsWhenFull(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "click_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE "urls"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// The worker is not running yet, so the single slot fills up
+	recorder := NewClickRecorder(db, 1, 10, time.Hour)
+	assert.True(t, recorder.Enqueue(&models.ClickEvent{URLID: 1, ShortCode: "one"}))
+	assert.False(t, recorder.Enqueue(&models.ClickEvent{URLID: 1, ShortCode: "one"}))
+
+	recorder.Start()
+	require.NoError(t, recorder.Close(context.Background()))
+	assert.False(t, recorder.Enqueue(&models.ClickEvent{URLID: 1, ShortCode: "one"}))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, ClickRecorderStats{Flushed: 1, Dropped: 2}, recorder.Stats())
+}
+
+func TestClickRecorder_FlushFailureIsCounted(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "click_events"`).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	recorder := NewClickRecorder(db, 10, 10, time.Hour)
+	recorder.Start()
+	recorder.Enqueue(&models.ClickEvent{URLID: 1, ShortCode: "one"})
+
+	require.NoError(t, recorder.Close(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, ClickRecorderStats{Failed: 1}, recorder.Stats())
+}
+
+func TestClickRecorder_FailedBatchKeepsOtherURLs(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	// The batch fails because URL 1 was purged; URL 2's click is retried alone
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "click_events"`).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "click_events"`).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "click_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec(`UPDATE "urls"`).
+		WithArgs(int64(1), sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	recorder := NewClickRecorder(db, 10, 10, time.Hour)
+	recorder.Start()
+	recorder.Enqueue(&models.ClickEvent{URLID: 2, ShortCode: "two"})
+	recorder.Enqueue(&models.ClickEvent{URLID: 1, ShortCode: "one"})
+	recorder.Enqueue(&models.ClickEvent{URLID: 1, ShortCode: "one"})
+
+	require.NoError(t, recorder.Close(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, ClickRecorderStats{Flushed: 1, Failed: 2}, recorder.Stats())
+}
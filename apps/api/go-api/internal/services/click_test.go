@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestURLService_RecordClick(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		{
+			name: "event and counter written together",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "click_events"`).
+					WithArgs(uint(1), "abc123", "https://ref.example", "curl/8.0", "203.0.113.0", "en-US", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(`UPDATE "urls" SET "clicks"=clicks \+ \$1,"clicks_at"=GREATEST\(clicks_at, \$2\) WHERE id = \$3`).
+					WithArgs(int64(1), sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "counter failure rolls back the event",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "click_events"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(`UPDATE "urls"`).
+					WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupTestDB(t)
+			tt.mock(mock)
+
+			service := NewURLService(db)
+			err := service.RecordClick(context.Background(), &models.ClickEvent{
+				URLID:          1,
+				ShortCode:      "abc123",
+				Referrer:       "https://ref.example",
+				UserAgent:      "curl/8.0",
+				IPAddress:      "203.0.113.7",
+				AcceptLanguage: "en-US",
+			})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestURLService_ClaimClick(t *testing.T) {
+	db, mock := setupTestDB(t)
+
+	claim := `UPDATE "urls" SET "clicks"=clicks \+ 1,"clicks_at"=GREATEST\(clicks_at, \$1\) WHERE \(id = \$2 AND \(max_clicks IS NULL OR clicks < max_clicks\)\)`
+	mock.ExpectBegin()
+	mock.ExpectExec(claim).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "click_events"`).
+		WithArgs(uint(1), "once", "", "curl/8.0", "203.0.113.0", "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(claim).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	// The recorder never flushes, so only the claim itself can hold the limit
+	recorder := NewClickRecorder(db, 10, 10, time.Hour)
+	service := NewURLService(db, WithClickRecorder(recorder))
+
+	visit := func() *models.ClickEvent {
+		return &models.ClickEvent{URLID: 1, ShortCode: "once", UserAgent: "curl/8.0", IPAddress: "203.0.113.7"}
+	}
+	require.NoError(t, service.ClaimClick(context.Background(), visit()))
+	assert.ErrorIs(t, service.ClaimClick(context.Background(), visit()), ErrClickLimitReached)
+	assert.Equal(t, ClickRecorderStats{}, recorder.Stats())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "203.0.113.7", want: "203.0.113.0"},
+		{ip: "2001:db8:abcd:12::1", want: "2001:db8:abcd::"},
+		{ip: "not-an-ip", want: ""},
+		{ip: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.want, AnonymizeIP(tt.ip))
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxRedirectHops bounds how far a chain of RefURL links is followed
+const maxRedirectHops = 5
+
+// DefaultAllowedSchemes are the destination schemes accepted by default
+var DefaultAllowedSchemes = []string{"http", "https"}
+
+var (
+	ErrInvalidDestination = errors.New("invalid destination")
+	ErrBlockedDomain      = fmt.Errorf("%w: domain is blocked", ErrInvalidDestination)
+	ErrRedirectLoop       = fmt.Errorf("%w: redirect loop", ErrInvalidDestination)
+
+	ErrBlockedDomainNotFound = errors.New("blocked domain not found")
+	ErrDomainAlreadyBlocked  = errors.New("domain is already blocked")
+)
+
+// redirectPath matches the paths RefURL serves short links on, whatever host
+// is in front of it
+var redirectPath = regexp.MustCompile(`^/(?:api/urls/go|go)/([A-Za-z0-9_-]+)/?$`)
+
+// DestinationPolicy decides which URLs links may point to
+type DestinationPolicy struct {
+	db        *gorm.DB
+	schemes   map[string]struct{}
+	selfHosts map[string]struct{}
+}
+
+// NewDestinationPolicy returns a policy accepting the given schemes, or
+// DefaultAllowedSchemes when schemes is nil, and rejecting selfHosts, the
+// hosts this service is reachable on
+func NewDestinationPolicy(db *gorm.DB, schemes, selfHosts []string) *DestinationPolicy {
+	if schemes == nil {
+		schemes = DefaultAllowedSchemes
+	}
+
+	p := &DestinationPolicy{
+		db:        db,
+		schemes:   make(map[string]struct{}, len(schemes)),
+		selfHosts: make(map[string]struct{}, len(selfHosts)),
+	}
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(strings.TrimSpace(scheme))] = struct{}{}
+	}
+	for _, host := range selfHosts {
+		p.selfHosts[normalizeHost(host)] = struct{}{}
+	}
+	return p
+}
+
+// Check validates destination for the link stored under shortCode, which is
+// empty for links that do not have a code yet
+func (p *DestinationPolicy) Check(ctx context.Context, destination, shortCode string) error {
+	u, err := url.Parse(strings.TrimSpace(destination))
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%w: must be an absolute URL", ErrInvalidDestination)
+	}
+	if _, ok := p.schemes[strings.ToLower(u.Scheme)]; !ok {
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrInvalidDestination, u.Scheme)
+	}
+
+	host := normalizeHost(u.Hostname())
+	if _, ok := p.selfHosts[host]; ok {
+		return fmt.Errorf("%w: links may not point back to this service", ErrInvalidDestination)
+	}
+
+	blocked, err := p.isBlocked(ctx, host)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlockedDomain
+	}
+
+	return p.checkLoop(ctx, u, shortCode)
+}
+
+// isBlocked reports whether host or any of its parent domains is blocked
+func (p *DestinationPolicy) isBlocked(ctx context.Context, host string) (bool, error) {
+	labels := strings.Split(host, ".")
+	candidates := make([]string, 0, len(labels))
+	for i := range labels {
+		candidates = append(candidates, strings.Join(labels[i:], "."))
+	}
+
+	var count int64
+	err := p.db.WithContext(ctx).Model(&models.BlockedDomain{}).Where("domain IN ?", candidates).Count(&count).Error
+	return count > 0, err
+}
+
+// checkLoop follows destinations that are themselves RefURL links through the
+// database and rejects chains that come back around or run too long. Hosts in
+// front of the service are not always known, so links are recognized by path.
+func (p *DestinationPolicy) checkLoop(ctx context.Context, u *url.URL, shortCode string) error {
+	seen := make(map[string]struct{})
+	if shortCode != "" {
+		seen[shortCode] = struct{}{}
+	}
+
+	for hop := 0; hop < maxRedirectHops; hop++ {
+		match := redirectPath.FindStringSubmatch(u.Path)
+		if match == nil {
+			return nil
+		}
+		code := match[1]
+		if _, ok := seen[code]; ok {
+			return ErrRedirectLoop
+		}
+		seen[code] = struct{}{}
+
+		var next []string
+		if err := p.db.WithContext(ctx).Model(&models.URL{}).Where("short_code = ?", code).Limit(1).Pluck("original_url", &next).Error; err != nil {
+			return err
+		}
+		if len(next) == 0 {
+			return nil
+		}
+
+		parsed, err := url.Parse(next[0])
+		if err != nil {
+			return nil
+		}
+		u = parsed
+	}
+
+	return fmt.Errorf("%w: more than %d hops", ErrRedirectLoop, maxRedirectHops)
+}
+
+// BlockDomain adds domain to the blocklist
+func (p *DestinationPolicy) BlockDomain(ctx context.Context, domain, reason string) (*models.BlockedDomain, error) {
+	domain = normalizeHost(domain)
+	if domain == "" {
+		return nil, fmt.Errorf("%w: domain is required", ErrInvalidDestination)
+	}
+
+	blocked := &models.BlockedDomain{Domain: domain, Reason: reason}
+	if err := p.db.WithContext(ctx).Create(blocked).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			return nil, ErrDomainAlreadyBlocked
+		}
+		return nil, err
+	}
+	return blocked, nil
+}
+
+// UnblockDomain removes domain from the blocklist
+func (p *DestinationPolicy) UnblockDomain(ctx context.Context, domain string) error {
+	result := p.db.WithContext(ctx).Where("domain = ?", normalizeHost(domain)).Delete(&models.BlockedDomain{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBlockedDomainNotFound
+	}
+	return nil
+}
+
+// ListBlockedDomains returns the blocklist
+func (p *DestinationPolicy) ListBlockedDomains(ctx context.Context) ([]models.BlockedDomain, error) {
+	var domains []models.BlockedDomain
+	if err := p.db.WithContext(ctx).Order("domain").Find(&domains).Error; err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
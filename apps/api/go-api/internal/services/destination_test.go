@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestinationPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		shortCode   string
+		mock        func(mock sqlmock.Sqlmock)
+		wantErr     error
+	}{
+		{
+			name:        "allowed",
+			destination: "https://example.com/page",
+			mock:        expectDestinationAllowed,
+		},
+		{
+			name:        "relative url",
+			destination: "/somewhere",
+			mock:        func(mock sqlmock.Sqlmock) {},
+			wantErr:     ErrInvalidDestination,
+		},
+		{
+			name:        "scheme not allowed",
+			destination: "ftp://example.com/file",
+			mock:        func(mock sqlmock.Sqlmock) {},
+			wantErr:     ErrInvalidDestination,
+		},
+		{
+			name:        "own host",
+			destination: "https://REF.example.org./api/urls/go/abc",
+			mock:        func(mock sqlmock.Sqlmock) {},
+			wantErr:     ErrInvalidDestination,
+		},
+		{
+			name:        "subdomain of blocked domain",
+			destination: "http://cdn.bad.example.net/x",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "blocked_domains" WHERE domain IN \(\$1,\$2,\$3,\$4\)`).
+					WithArgs("cdn.bad.example.net", "bad.example.net", "example.net", "net").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			},
+			wantErr: ErrBlockedDomain,
+		},
+		{
+			name:        "chain to unknown code",
+			destination: "https://short.example.com/go/other",
+			shortCode:   "mine",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectDestinationAllowed(mock)
+				mock.ExpectQuery(`SELECT "original_url" FROM "urls" WHERE short_code = \$1`).
+					WithArgs("other", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_url"}))
+			},
+		},
+		{
+			name:        "loop back through another code",
+			destination: "https://short.example.com/api/urls/go/other",
+			shortCode:   "mine",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectDestinationAllowed(mock)
+				mock.ExpectQuery(`SELECT "original_url" FROM "urls" WHERE short_code = \$1`).
+					WithArgs("other", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_url"}).AddRow("https://mirror.example.com/go/mine"))
+			},
+			wantErr: ErrRedirectLoop,
+		},
+		{
+			name:        "chain too long",
+			destination: "https://short.example.com/go/c0",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectDestinationAllowed(mock)
+				next := []string{"c1", "c2", "c3", "c4", "c5"}
+				for _, code := range next {
+					mock.ExpectQuery(`SELECT "original_url" FROM "urls"`).
+						WillReturnRows(sqlmock.NewRows([]string{"original_url"}).AddRow("https://short.example.com/go/" + code))
+				}
+			},
+			wantErr: ErrRedirectLoop,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupTestDB(t)
+			tt.mock(mock)
+			policy := NewDestinationPolicy(db, nil, []string{"ref.example.org"})
+
+			err := policy.Check(context.Background(), tt.destination, tt.shortCode)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDestinationPolicy_BlockDomain(t *testing.T) {
+	db, mock := setupTestDB(t)
+	policy := NewDestinationPolicy(db, nil, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "blocked_domains"`).
+		WithArgs("bad.example.net", "phishing", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	blocked, err := policy.BlockDomain(context.Background(), " Bad.Example.NET. ", "phishing")
+	require.NoError(t, err)
+	assert.Equal(t, "bad.example.net", blocked.Domain)
+
+	_, err = policy.BlockDomain(context.Background(), "  ", "")
+	assert.ErrorIs(t, err, ErrInvalidDestination)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/mailer"
+)
+
+const (
+	// DefaultEmailVerificationTTL is how long a verification link works
+	DefaultEmailVerificationTTL = 48 * time.Hour
+	// DefaultUnverifiedLinkLimit caps the links of users who have not
+	// verified their email yet
+	DefaultUnverifiedLinkLimit = 5
+	// maxVerificationEmails limits how many verification emails one user
+	// can have sent per verificationEmailWindow
+	maxVerificationEmails   = 3
+	verificationEmailWindow = time.Hour
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification link")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+	ErrEmailNotVerified         = errors.New("email is not verified")
+	ErrTooManyVerificationMails = errors.New("too many verification emails, try again later")
+)
+
+// EmailVerifier emails users a signed link that confirms their address
+type EmailVerifier struct {
+	secret    []byte
+	ttl       time.Duration
+	mailer    mailer.Mailer
+	verifyURL string
+	limiter   *AttemptLimiter
+}
+
+// NewEmailVerifier returns a verifier signing links with secret and sending
+// them through m. verifyURL is the address of GET /api/auth/verify.
+func NewEmailVerifier(secret string, ttl time.Duration, m mailer.Mailer, verifyURL string) *EmailVerifier {
+	if ttl <= 0 {
+		ttl = DefaultEmailVerificationTTL
+	}
+	return &EmailVerifier{
+		secret:    []byte(secret),
+		ttl:       ttl,
+		mailer:    m,
+		verifyURL: verifyURL,
+		limiter:   NewAttemptLimiter(maxVerificationEmails, verificationEmailWindow),
+	}
+}
+
+// Sign returns a token verifying email for the user with the given ID
+func (v *EmailVerifier) Sign(userID uint, email string, now time.Time) string {
+	payload := fmt.Sprintf("%d.%d", userID, now.Add(v.ttl).Unix())
+	return payload + "." + v.mac(payload, email)
+}
+
+// UserID returns the user a token claims to be for, before it is verified
+func (v *EmailVerifier) UserID(token string) (uint, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 32)
+	return uint(id), err == nil
+}
+
+// Verify reports whether token verifies email for the user with the given ID
+func (v *EmailVerifier) Verify(token string, userID uint, email string, now time.Time) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(v.mac(payload, email))) {
+		return false
+	}
+	if parts[0] != strconv.FormatUint(uint64(userID), 10) {
+		return false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	return err == nil && now.Unix() < expires
+}
+
+// Send emails user a verification link
+func (v *EmailVerifier) Send(ctx context.Context, user *models.User, now time.Time) error {
+	key := strconv.FormatUint(uint64(user.ID), 10)
+	if !v.limiter.Allow(key) {
+		return ErrTooManyVerificationMails
+	}
+
+	link := v.verifyURL + "?token=" + url.QueryEscape(v.Sign(user.ID, user.Email, now))
+	return v.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in %s. If you did not sign up, you can ignore this email.\n",
+			user.Name, link, v.ttl),
+	})
+}
+
+// mac signs payload for email. The purpose prefix keeps these tokens apart
+// from other tokens signed with the same secret, and the email stops a link
+// from verifying an address it was not sent to.
+func (v *EmailVerifier) mac(payload, email string) string {
+	h := hmac.New(sha256.New, v.secret)
+	h.Write([]byte("verify-email." + payload + "." + strings.ToLower(email)))
+	return hex.EncodeToString(h.Sum(nil))
+}
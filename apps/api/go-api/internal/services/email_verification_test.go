@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/mailer"
+)
+
+func TestEmailVerifier_Verify(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	verifier := NewEmailVerifier("secret", time.Hour, mailer.NewMemoryMailer(), "https://refurl.example/api/auth/verify")
+	token := verifier.Sign(7, "Ann@example.com", now)
+
+	assert.True(t, verifier.Verify(token, 7, "ann@example.com", now.Add(59*time.Minute)))
+	assert.False(t, verifier.Verify(token, 7, "ann@example.com", now.Add(time.Hour)), "expired")
+	assert.False(t, verifier.Verify(token, 8, "ann@example.com", now), "other user")
+	assert.False(t, verifier.Verify(token, 7, "eve@example.com", now), "other email")
+	assert.False(t, verifier.Verify(strings.Replace(token, "7.", "8.", 1), 8, "ann@example.com", now), "tampered")
+
+	// Unlock tokens share the secret but must not pass as verification tokens
+	unlock := NewLinkLock("secret", time.Hour, nil, nil).Sign(7, "", now)
+	assert.False(t, verifier.Verify(unlock, 7, "ann@example.com", now))
+
+	userID, ok := verifier.UserID(token)
+	assert.True(t, ok)
+	assert.Equal(t, uint(7), userID)
+}
+
+func TestEmailVerifier_Send(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	memory := mailer.NewMemoryMailer()
+	verifier := NewEmailVerifier("secret", time.Hour, memory, "https://refurl.example/api/auth/verify")
+	user := &models.User{ID: 7, Name: "Ann", Email: "ann@example.com"}
+
+	require.NoError(t, verifier.Send(context.Background(), user, now))
+
+	messages := memory.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "ann@example.com", messages[0].To)
+	link := "https://refurl.example/api/auth/verify?token=" + url.QueryEscape(verifier.Sign(7, "ann@example.com", now))
+	assert.Contains(t, messages[0].Body, link)
+
+	for i := 1; i < maxVerificationEmails; i++ {
+		require.NoError(t, verifier.Send(context.Background(), user, now))
+	}
+	assert.ErrorIs(t, verifier.Send(context.Background(), user, now), ErrTooManyVerificationMails)
+}
+
+func TestAuthService_VerifyEmail(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	userColumns := []string{"id", "email", "email_verified_at"}
+
+	newService := func(t *testing.T) (*AuthService, *EmailVerifier, sqlmock.Sqlmock) {
+		db, mock := setupTestDB(t)
+		verifier := NewEmailVerifier("secret", time.Hour, mailer.NewMemoryMailer(), "https://refurl.example/api/auth/verify")
+		service := NewAuthService(db, "secret", WithEmailVerifier(verifier))
+		service.now = func() time.Time { return now }
+		return service, verifier, mock
+	}
+
+	t.Run("verified", func(t *testing.T) {
+		service, verifier, mock := newService(t)
+
+		mock.ExpectQuery(`SELECT "id","email","email_verified_at" FROM "users" WHERE id = \$1`).
+			WithArgs(uint(7), 1).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(7, "ann@example.com", nil))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "users" SET "email_verified_at"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+			WithArgs(now, sqlmock.AnyArg(), uint(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := service.VerifyEmail(context.Background(), verifier.Sign(7, "ann@example.com", now))
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("token for another address", func(t *testing.T) {
+		service, verifier, mock := newService(t)
+
+		mock.ExpectQuery(`SELECT "id","email","email_verified_at" FROM "users" WHERE id = \$1`).
+			WithArgs(uint(7), 1).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(7, "ann@example.com", nil))
+
+		err := service.VerifyEmail(context.Background(), verifier.Sign(7, "old@example.com", now))
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("malformed token", func(t *testing.T) {
+		service, _, mock := newService(t)
+
+		err := service.VerifyEmail(context.Background(), "not-a-token")
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestURLService_UnverifiedLinkLimit(t *testing.T) {
+	expectQuota := func(mock sqlmock.Sqlmock, owned int) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT "id","email_verified_at" FROM "users" WHERE id = \$1 AND "users"\."deleted_at" IS NULL LIMIT \$2 FOR UPDATE`).
+			WithArgs(uint(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email_verified_at"}).AddRow(1, nil))
+		// No deleted_at condition: links in the trash count as well
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "urls" WHERE owner = \$1$`).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(owned))
+	}
+
+	t.Run("room left", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db, WithUnverifiedLinkLimit(2))
+
+		expectDestinationAllowed(mock)
+		expectQuota(mock, 1)
+		mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "urls"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+		mock.ExpectCommit()
+
+		url, err := service.CreateURL(context.Background(), 1, &models.CreateURLRequest{OriginalURL: "https://example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(5), url.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("deleting a link does not make room", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db, WithUnverifiedLinkLimit(2))
+
+		// One of the two links was moved to the trash before this create
+		expectDestinationAllowed(mock)
+		expectQuota(mock, 2)
+		mock.ExpectRollback()
+
+		_, err := service.CreateURL(context.Background(), 1, &models.CreateURLRequest{OriginalURL: "https://example.com"})
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+		assert.True(t, isRejection(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// DefaultExpirySweepInterval is used when no sweep interval is configured
+const DefaultExpirySweepInterval = time.Minute
+
+var ErrInvalidExpiry = errors.New("invalid expiry")
+
+// validateExpiry checks the expiration settings of a create or update request
+func validateExpiry(expiresAt *time.Time, maxClicks *int64, now time.Time) error {
+	if expiresAt != nil && !expiresAt.After(now) {
+		return fmt.Errorf("%w: expires_at must be in the future", ErrInvalidExpiry)
+	}
+	if maxClicks != nil && *maxClicks <= 0 {
+		return fmt.Errorf("%w: max_clicks must be positive", ErrInvalidExpiry)
+	}
+	return nil
+}
+
+// validateExpiryUpdate checks the expiration settings an update changes,
+// leaving the stored ones alone so other edits to a link that is already
+// past its limits still go through. It reports whether the settings changed.
+func validateExpiryUpdate(url *models.URL, expiresAt *time.Time, maxClicks *int64, now time.Time) (bool, error) {
+	changedExpiry := !sameTime(url.ExpiresAt, expiresAt)
+	changedLimit := !sameInt64(url.MaxClicks, maxClicks)
+	if !changedExpiry {
+		expiresAt = nil
+	}
+	if !changedLimit {
+		maxClicks = nil
+	}
+	return changedExpiry || changedLimit, validateExpiry(expiresAt, maxClicks, now)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameInt64(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// URLExpired reports whether url has passed its expiry date or click limit.
+// The click count may be stale, so links with a limit are only followed once
+// ClaimClick has counted the visit.
+func URLExpired(url *models.URLResponse, now time.Time) bool {
+	if url.Expired {
+		return true
+	}
+	if url.ExpiresAt != nil && !now.Before(*url.ExpiresAt) {
+		return true
+	}
+	return url.MaxClicks != nil && url.Clicks >= *url.MaxClicks
+}
+
+// ExpirySweeper periodically marks links that reached their expiry date or
+// click limit as expired
+type ExpirySweeper struct {
+	db       *gorm.DB
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewExpirySweeper(db *gorm.DB, interval time.Duration) *ExpirySweeper {
+	if interval <= 0 {
+		interval = DefaultExpirySweepInterval
+	}
+	return &ExpirySweeper{
+		db:       db,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start launches the background sweep loop
+func (s *ExpirySweeper) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.Sweep(time.Now()); err != nil {
+					logger.Error("Failed to sweep expired URLs: %v", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the sweep loop and waits for a running sweep to finish
+func (s *ExpirySweeper) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Sweep marks every link expired as of now and returns how many it marked
+func (s *ExpirySweeper) Sweep(now time.Time) (int64, error) {
+	result := s.db.Model(&models.URL{}).
+		Where("expired = ?", false).
+		Where("(expires_at IS NOT NULL AND expires_at <= ?) OR (max_clicks IS NOT NULL AND clicks >= max_clicks)", now).
+		Update("expired", true)
+	return result.RowsAffected, result.Error
+}
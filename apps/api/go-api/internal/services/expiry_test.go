@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestURLExpired(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+	limit := int64(10)
+
+	tests := []struct {
+		name string
+		url  models.URLResponse
+		want bool
+	}{
+		{name: "no limits", url: models.URLResponse{Clicks: 100}, want: false},
+		{name: "before expiry date", url: models.URLResponse{ExpiresAt: &future}, want: false},
+		{name: "past expiry date", url: models.URLResponse{ExpiresAt: &past}, want: true},
+		{name: "under click limit", url: models.URLResponse{MaxClicks: &limit, Clicks: 9}, want: false},
+		{name: "click limit reached", url: models.URLResponse{MaxClicks: &limit, Clicks: 10}, want: true},
+		{name: "marked by sweeper", url: models.URLResponse{Expired: true}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, URLExpired(&tt.url, now))
+		})
+	}
+}
+
+func TestValidateExpiry(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+	zero := int64(0)
+	ten := int64(10)
+
+	assert.NoError(t, validateExpiry(nil, nil, now))
+	assert.NoError(t, validateExpiry(&future, &ten, now))
+	assert.ErrorIs(t, validateExpiry(&past, nil, now), ErrInvalidExpiry)
+	assert.ErrorIs(t, validateExpiry(nil, &zero, now), ErrInvalidExpiry)
+}
+
+func TestExpirySweeper_Sweep(t *testing.T) {
+	db, mock := setupTestDB(t)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "urls" SET "expired"=\$1 WHERE expired = \$2 AND \(\(expires_at IS NOT NULL AND expires_at <= \$3\) OR \(max_clicks IS NOT NULL AND clicks >= max_clicks\)\)`).
+		WithArgs(true, false, now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	sweeper := NewExpirySweeper(db, time.Minute)
+	marked, err := sweeper.Sweep(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), marked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
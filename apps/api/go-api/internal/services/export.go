@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// URLExportWriter receives an export as rows are read from the database
+type URLExportWriter interface {
+	WriteURL(url *models.URLResponse) error
+	// WriteClick is called with the click events of the last link written
+	WriteClick(click *models.ClickEvent) error
+}
+
+// ExportURLs writes every link userID owns, oldest first, to w one row at a
+// time. With includeClicks each link is followed by its click events, which
+// are read from a second query ordered the same way and merged in.
+func (s *URLService) ExportURLs(ctx context.Context, userID uint, includeClicks bool, w URLExportWriter) error {
+	urlRows, err := s.db.WithContext(ctx).Model(&models.URL{}).
+		Where("owner = ?", userID).
+		Order("id").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer urlRows.Close()
+
+	var clicks *clickCursor
+	if includeClicks {
+		rows, err := s.db.WithContext(ctx).Model(&models.ClickEvent{}).
+			Joins("JOIN urls ON urls.id = click_events.url_id").
+			Where("urls.owner = ? AND urls.deleted_at IS NULL", userID).
+			Order("click_events.url_id, click_events.id").
+			Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		clicks = &clickCursor{db: s.db, rows: rows}
+	}
+
+	for urlRows.Next() {
+		var url models.URL
+		if err := s.db.ScanRows(urlRows, &url); err != nil {
+			return err
+		}
+		if err := w.WriteURL(toURLResponse(&url)); err != nil {
+			return err
+		}
+		if clicks != nil {
+			if err := clicks.writeFor(url.ID, w); err != nil {
+				return err
+			}
+		}
+	}
+	return urlRows.Err()
+}
+
+// clickCursor walks click events ordered by link, holding back the first
+// event of the next link until that link is written
+type clickCursor struct {
+	db   *gorm.DB
+	rows *sql.Rows
+	next *models.ClickEvent
+}
+
+func (c *clickCursor) writeFor(urlID uint, w URLExportWriter) error {
+	for {
+		if c.next == nil {
+			if !c.rows.Next() {
+				return c.rows.Err()
+			}
+			c.next = &models.ClickEvent{}
+			if err := c.db.ScanRows(c.rows, c.next); err != nil {
+				return err
+			}
+		}
+		if c.next.URLID > urlID {
+			return nil
+		}
+		if c.next.URLID == urlID {
+			if err := w.WriteClick(c.next); err != nil {
+				return err
+			}
+		}
+		c.next = nil
+	}
+}
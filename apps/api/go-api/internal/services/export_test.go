@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// recordingExportWriter notes the rows it is given as "url:<code>" and
+// "click:<id>"
+type recordingExportWriter struct {
+	rows []string
+}
+
+func (r *recordingExportWriter) WriteURL(url *models.URLResponse) error {
+	r.rows = append(r.rows, "url:"+url.ShortCode)
+	return nil
+}
+
+func (r *recordingExportWriter) WriteClick(click *models.ClickEvent) error {
+	r.rows = append(r.rows, "click:"+click.Referrer)
+	return nil
+}
+
+func TestURLService_ExportURLs(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewURLService(db)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE owner = \$1 AND "urls"\."deleted_at" IS NULL ORDER BY id`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_code", "owner", "created_at"}).
+			AddRow(1, "one", 1, now).
+			AddRow(2, "two", 1, now).
+			AddRow(3, "three", 1, now))
+	mock.ExpectQuery(`SELECT "click_events"\."id",.* FROM "click_events" JOIN urls ON urls\.id = click_events\.url_id WHERE urls\.owner = \$1 AND urls\.deleted_at IS NULL ORDER BY click_events\.url_id, click_events\.id`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url_id", "referrer"}).
+			AddRow(10, 1, "a").
+			AddRow(11, 1, "b").
+			AddRow(12, 3, "c"))
+
+	w := &recordingExportWriter{}
+	require.NoError(t, service.ExportURLs(context.Background(), 1, true, w))
+	assert.Equal(t, []string{"url:one", "click:a", "click:b", "url:two", "url:three", "click:c"}, w.rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestURLService_ExportURLs_WithoutClicks(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewURLService(db)
+
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE owner = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_code"}).AddRow(1, "one"))
+
+	w := &recordingExportWriter{}
+	require.NoError(t, service.ExportURLs(context.Background(), 1, false, w))
+	assert.Equal(t, []string{"url:one"}, w.rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
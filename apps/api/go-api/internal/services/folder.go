@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// MaxFolderDepth bounds the number of ancestors a folder may have when it
+// is created or moved
+const MaxFolderDepth = 10
+
+var (
+	ErrFolderNotFound = errors.New("folder not found")
+	// ErrInvalidFolderParent rejects a parent the user does not own, or one
+	// that would put a folder inside itself or nest folders too deeply
+	ErrInvalidFolderParent = errors.New("invalid parent folder")
+)
+
+type FolderServiceInterface interface {
+	ListFolders(ctx context.Context, userID uint) ([]models.FolderResponse, error)
+	CreateFolder(ctx context.Context, userID uint, req *models.FolderRequest) (*models.FolderResponse, error)
+	UpdateFolder(ctx context.Context, userID uint, id uint, req *models.FolderRequest) (*models.FolderResponse, error)
+	DeleteFolder(ctx context.Context, userID uint, id uint) error
+	MoveURL(ctx context.Context, userID uint, urlID uint, folderID *uint) error
+}
+
+type FolderService struct {
+	db *gorm.DB
+}
+
+func NewFolderService(db *gorm.DB) *FolderService {
+	return &FolderService{db: db}
+}
+
+// ListFolders returns all of userID's folders by name, each with the number
+// of links in use directly inside it. Clients build the tree from ParentID.
+func (s *FolderService) ListFolders(ctx context.Context, userID uint) ([]models.FolderResponse, error) {
+	folders := []models.FolderResponse{}
+	err := s.foldersWithLinks(ctx).
+		Where("folders.owner = ?", userID).
+		Order("folders.name, folders.id").
+		Scan(&folders).Error
+	if err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+func (s *FolderService) CreateFolder(ctx context.Context, userID uint, req *models.FolderRequest) (*models.FolderResponse, error) {
+	if err := s.checkParent(ctx, userID, 0, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	folder := &models.Folder{Owner: userID, ParentID: req.ParentID, Name: req.Name}
+	if err := s.db.WithContext(ctx).Create(folder).Error; err != nil {
+		return nil, err
+	}
+	return &models.FolderResponse{ID: folder.ID, Name: folder.Name, ParentID: folder.ParentID, CreatedAt: folder.CreatedAt}, nil
+}
+
+// UpdateFolder renames a folder and moves it under req.ParentID
+func (s *FolderService) UpdateFolder(ctx context.Context, userID uint, id uint, req *models.FolderRequest) (*models.FolderResponse, error) {
+	if _, err := s.ownedFolder(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	if err := s.checkParent(ctx, userID, id, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Model(&models.Folder{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"name": req.Name, "parent_id": req.ParentID}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var folder models.FolderResponse
+	if err := s.foldersWithLinks(ctx).Where("folders.id = ?", id).Scan(&folder).Error; err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// DeleteFolder deletes a folder, moving its subfolders and links, including
+// those in the trash, up to its parent
+func (s *FolderService) DeleteFolder(ctx context.Context, userID uint, id uint) error {
+	folder, err := s.ownedFolder(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Folder{}).Where("parent_id = ?", id).Update("parent_id", folder.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.URL{}).Where("folder_id = ?", id).Update("folder_id", folder.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Folder{}, id).Error
+	})
+}
+
+// MoveURL puts a link in a folder, or in none when folderID is nil
+func (s *FolderService) MoveURL(ctx context.Context, userID uint, urlID uint, folderID *uint) error {
+	if folderID != nil {
+		if _, err := s.ownedFolder(ctx, userID, *folderID); err != nil {
+			return err
+		}
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.URL{}).
+		Where("id = ? AND owner = ?", urlID, userID).
+		Update("folder_id", folderID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrURLNotFound
+	}
+	return nil
+}
+
+func (s *FolderService) ownedFolder(ctx context.Context, userID uint, id uint) (*models.Folder, error) {
+	var folder models.Folder
+	if err := s.db.WithContext(ctx).Where("id = ? AND owner = ?", id, userID).First(&folder).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFolderNotFound
+		}
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// checkParent walks up from parentID to the top level, rejecting parents
+// userID does not own, paths through the folder being moved (id, or 0 for
+// a new folder) and more than MaxFolderDepth ancestors
+func (s *FolderService) checkParent(ctx context.Context, userID uint, id uint, parentID *uint) error {
+	for depth := 0; parentID != nil; depth++ {
+		if *parentID == id {
+			return fmt.Errorf("%w: a folder cannot be moved inside itself", ErrInvalidFolderParent)
+		}
+		if depth == MaxFolderDepth {
+			return fmt.Errorf("%w: folders nest at most %d deep", ErrInvalidFolderParent, MaxFolderDepth)
+		}
+		parent, err := s.ownedFolder(ctx, userID, *parentID)
+		if errors.Is(err, ErrFolderNotFound) {
+			return fmt.Errorf("%w: %v", ErrInvalidFolderParent, err)
+		}
+		if err != nil {
+			return err
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+// foldersWithLinks selects folders as FolderResponses, counting the links
+// in use directly inside each
+func (s *FolderService) foldersWithLinks(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&models.Folder{}).
+		Select("folders.id, folders.name, folders.parent_id, folders.created_at, COUNT(urls.id) AS links").
+		Joins("LEFT JOIN urls ON urls.folder_id = folders.id AND urls.deleted_at IS NULL").
+		Group("folders.id")
+}
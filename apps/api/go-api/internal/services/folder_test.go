@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// expectFolder expects a lookup of one of user 1's folders
+func expectFolder(mock sqlmock.Sqlmock, id uint, parentID interface{}) {
+	mock.ExpectQuery(`SELECT \* FROM "folders" WHERE id = \$1 AND owner = \$2`).
+		WithArgs(id, uint(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner", "parent_id", "name"}).AddRow(id, 1, parentID, "folder"))
+}
+
+func TestFolderService_UpdateFolder_RejectsCycles(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewFolderService(db)
+
+	// Moving folder 1 under 3, whose parent 2 sits inside folder 1
+	expectFolder(mock, 1, nil)
+	expectFolder(mock, 3, 2)
+	expectFolder(mock, 2, 1)
+
+	parent := uint(3)
+	_, err := service.UpdateFolder(context.Background(), 1, 1, &models.FolderRequest{Name: "Campaigns", ParentID: &parent})
+	assert.ErrorIs(t, err, ErrInvalidFolderParent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFolderService_CreateFolder(t *testing.T) {
+	t.Run("under a parent", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewFolderService(db)
+
+		expectFolder(mock, 2, nil)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "folders" \("owner","parent_id","name","created_at"\)`).
+			WithArgs(uint(1), uint(2), "Spring", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+		mock.ExpectCommit()
+
+		parent := uint(2)
+		folder, err := service.CreateFolder(context.Background(), 1, &models.FolderRequest{Name: "Spring", ParentID: &parent})
+		require.NoError(t, err)
+		assert.Equal(t, uint(5), folder.ID)
+		assert.Equal(t, &parent, folder.ParentID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("parent not owned", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewFolderService(db)
+
+		mock.ExpectQuery(`SELECT \* FROM "folders"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		parent := uint(9)
+		_, err := service.CreateFolder(context.Background(), 1, &models.FolderRequest{Name: "Spring", ParentID: &parent})
+		assert.ErrorIs(t, err, ErrInvalidFolderParent)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFolderService_DeleteFolder(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewFolderService(db)
+
+	expectFolder(mock, 4, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "folders" SET "parent_id"=\$1 WHERE parent_id = \$2`).
+		WithArgs(uint(2), uint(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "urls" SET "folder_id"=\$1 WHERE folder_id = \$2$`).
+		WithArgs(uint(2), uint(4)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`DELETE FROM "folders" WHERE "folders"\."id" = \$1`).
+		WithArgs(uint(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, service.DeleteFolder(context.Background(), 1, 4))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,19 +2,31 @@ package services
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
 type HealthService struct {
 	startTime time.Time
+
+	mu      sync.RWMutex
+	metrics map[string]func() interface{}
 }
 
 func NewHealthService() *HealthService {
 	return &HealthService{
 		startTime: time.Now(),
+		metrics:   make(map[string]func() interface{}),
 	}
 }
 
+// RegisterMetrics adds a named metrics source reported by GetDetailedStatus
+func (s *HealthService) RegisterMetrics(name string, source func() interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics[name] = source
+}
+
 type HealthStatus struct {
 	Status    string    `json:"status"`
 	Timestamp time.Time `json:"timestamp"`
@@ -31,6 +43,7 @@ type DetailedHealthStatus struct {
 			Status string `json:"status"`
 		} `json:"database"`
 	} `json:"services"`
+	Metrics map[string]interface{} `json:"metrics,omitempty"`
 }
 
 func (s *HealthService) GetStatus(ctx context.Context) (*HealthStatus, error) {
@@ -52,5 +65,14 @@ func (s *HealthService) GetDetailedStatus(ctx context.Context) (*DetailedHealthS
 	// Add database status
 	status.Services.Database.Status = "ok"
 
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.metrics) > 0 {
+		status.Metrics = make(map[string]interface{}, len(s.metrics))
+		for name, source := range s.metrics {
+			status.Metrics[name] = source()
+		}
+	}
+
 	return status, nil
 }
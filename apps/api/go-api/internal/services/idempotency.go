@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultIdempotencyKeyTTL is how long a response is kept for replay
+	DefaultIdempotencyKeyTTL = 24 * time.Hour
+	// DefaultIdempotencyLease is how long a request may hold its key before
+	// a retry may take it over
+	DefaultIdempotencyLease = 5 * time.Minute
+	// DefaultIdempotencyPurgeInterval is used when no purge interval is configured
+	DefaultIdempotencyPurgeInterval = time.Hour
+	// MaxIdempotencyKeyLength is the longest Idempotency-Key accepted
+	MaxIdempotencyKeyLength = 255
+)
+
+var (
+	// ErrIdempotencyKeyMismatch is returned when a key is reused for a
+	// different request
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was used for a different request")
+	// ErrIdempotencyKeyInProgress is returned when the request that first
+	// used a key has not finished yet
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+	// ErrIdempotencyLeaseLost is returned when a request finishes after a
+	// retry took its claim over, so the key is no longer its to settle
+	ErrIdempotencyLeaseLost = errors.New("idempotency key lease was taken over")
+)
+
+type IdempotencyServiceInterface interface {
+	Begin(ctx context.Context, userID uint, key string, requestHash string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, record *models.IdempotencyKey, statusCode int, body []byte) error
+	Release(ctx context.Context, record *models.IdempotencyKey) error
+}
+
+// IdempotencyService stores the responses to requests made with an
+// Idempotency-Key so that retries of them are answered without running them
+// again
+type IdempotencyService struct {
+	db    *gorm.DB
+	ttl   time.Duration
+	lease time.Duration
+	now   func() time.Time
+}
+
+func NewIdempotencyService(db *gorm.DB, ttl, lease time.Duration) *IdempotencyService {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyKeyTTL
+	}
+	if lease <= 0 {
+		lease = DefaultIdempotencyLease
+	}
+	return &IdempotencyService{db: db, ttl: ttl, lease: lease, now: time.Now}
+}
+
+// Begin claims key for userID's request with the given hash. A record with a
+// zero StatusCode belongs to the caller, who must Complete or Release it once
+// the request has been handled. A record with a StatusCode holds the stored
+// response of an earlier request to replay instead. A claim whose lease ran
+// out, because the request holding it never finished, is taken over.
+func (s *IdempotencyService) Begin(ctx context.Context, userID uint, key string, requestHash string) (*models.IdempotencyKey, error) {
+	now := s.now()
+
+	// An expired key is free to be used again
+	err := s.db.WithContext(ctx).
+		Where("owner = ? AND key = ? AND expires_at <= ?", userID, key, now).
+		Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.IdempotencyKey{
+		Owner:       userID,
+		Key:         key,
+		RequestHash: requestHash,
+		LockedUntil: s.leaseEnd(now),
+		ExpiresAt:   now.Add(s.ttl),
+	}
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return record, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := s.db.WithContext(ctx).Where("owner = ? AND key = ?", userID, key).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Released between our insert and this read; let the client retry
+			return nil, ErrIdempotencyKeyInProgress
+		}
+		return nil, err
+	}
+	if existing.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+	if existing.StatusCode == 0 {
+		return s.takeOver(ctx, &existing, now)
+	}
+	return &existing, nil
+}
+
+// takeOver claims record for the caller if the lease of the request that
+// claimed it has run out. Of several retries taking over at once, only the
+// first gets the record.
+func (s *IdempotencyService) takeOver(ctx context.Context, record *models.IdempotencyKey, now time.Time) (*models.IdempotencyKey, error) {
+	if now.Before(record.LockedUntil) {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	lockedUntil := s.leaseEnd(now)
+	result := s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("id = ? AND status_code = 0 AND locked_until <= ?", record.ID, now).
+		Update("locked_until", lockedUntil)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	record.LockedUntil = lockedUntil
+	return record, nil
+}
+
+// leaseEnd is when a lease taken at now runs out, at the precision the
+// database stores it with so that it can be matched exactly later
+func (s *IdempotencyService) leaseEnd(now time.Time) time.Time {
+	return now.Add(s.lease).Truncate(time.Microsecond)
+}
+
+// Complete stores the response to the request that claimed record. It
+// returns ErrIdempotencyLeaseLost if a retry has taken the claim over.
+func (s *IdempotencyService) Complete(ctx context.Context, record *models.IdempotencyKey, statusCode int, body []byte) error {
+	result := s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("id = ? AND locked_until = ?", record.ID, record.LockedUntil).
+		Updates(map[string]interface{}{"status_code": statusCode, "response_body": body})
+	return leaseResult(result)
+}
+
+// Release gives up the claim on record without storing a response, so a
+// retry runs the request again. It returns ErrIdempotencyLeaseLost if a
+// retry has taken the claim over.
+func (s *IdempotencyService) Release(ctx context.Context, record *models.IdempotencyKey) error {
+	result := s.db.WithContext(ctx).
+		Where("id = ? AND locked_until = ?", record.ID, record.LockedUntil).
+		Delete(&models.IdempotencyKey{})
+	return leaseResult(result)
+}
+
+func leaseResult(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdempotencyLeaseLost
+	}
+	return nil
+}
+
+// IdempotencyPurger periodically deletes idempotency keys past their expiry
+type IdempotencyPurger struct {
+	db       *gorm.DB
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewIdempotencyPurger(db *gorm.DB, interval time.Duration) *IdempotencyPurger {
+	if interval <= 0 {
+		interval = DefaultIdempotencyPurgeInterval
+	}
+	return &IdempotencyPurger{
+		db:       db,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start launches the background purge loop
+func (p *IdempotencyPurger) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := p.Purge(time.Now()); err != nil {
+					logger.Error("Failed to purge idempotency keys: %v", err)
+				}
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the purge loop and waits for a running purge to finish
+func (p *IdempotencyPurger) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+// Purge deletes the keys expired as of now and returns how many it deleted
+func (p *IdempotencyPurger) Purge(now time.Time) (int64, error) {
+	result := p.db.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
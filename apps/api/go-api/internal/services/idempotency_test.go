@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	keyColumns := []string{"id", "owner", "key", "request_hash", "status_code", "locked_until", "response_body"}
+	// takeOver is the number of rows the take-over of a stale claim updates,
+	// or -1 when no take-over is attempted
+	tests := []struct {
+		name       string
+		existing   *sqlmock.Rows
+		takeOver   int64
+		wantStatus int
+		wantErr    error
+	}{
+		{
+			name:     "new key",
+			takeOver: -1,
+		},
+		{
+			name:       "replay",
+			existing:   sqlmock.NewRows(keyColumns).AddRow(4, 1, "k1", "abc", 200, now, []byte(`{"status":"success"}`)),
+			takeOver:   -1,
+			wantStatus: 200,
+		},
+		{
+			name:     "different request",
+			existing: sqlmock.NewRows(keyColumns).AddRow(4, 1, "k1", "def", 200, now, []byte(`{}`)),
+			takeOver: -1,
+			wantErr:  ErrIdempotencyKeyMismatch,
+		},
+		{
+			name:     "still running",
+			existing: sqlmock.NewRows(keyColumns).AddRow(4, 1, "k1", "abc", 0, now.Add(time.Second), nil),
+			takeOver: -1,
+			wantErr:  ErrIdempotencyKeyInProgress,
+		},
+		{
+			name:     "lease ran out",
+			existing: sqlmock.NewRows(keyColumns).AddRow(4, 1, "k1", "abc", 0, now, nil),
+			takeOver: 1,
+		},
+		{
+			name:     "lease taken over by another retry",
+			existing: sqlmock.NewRows(keyColumns).AddRow(4, 1, "k1", "abc", 0, now.Add(-time.Minute), nil),
+			takeOver: 0,
+			wantErr:  ErrIdempotencyKeyInProgress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupTestDB(t)
+			service := NewIdempotencyService(db, time.Hour, time.Minute)
+			service.now = func() time.Time { return now }
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM "idempotency_keys" WHERE owner = \$1 AND key = \$2 AND expires_at <= \$3`).
+				WithArgs(uint(1), "k1", now).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			insert := mock.ExpectQuery(`INSERT INTO "idempotency_keys" .* ON CONFLICT DO NOTHING RETURNING "id"`).
+				WithArgs(uint(1), "k1", "abc", 0, now.Add(time.Minute), sqlmock.AnyArg(), sqlmock.AnyArg(), now.Add(time.Hour))
+			if tt.existing == nil {
+				insert.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+			} else {
+				insert.WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			}
+			mock.ExpectCommit()
+			if tt.existing != nil {
+				mock.ExpectQuery(`SELECT \* FROM "idempotency_keys" WHERE owner = \$1 AND key = \$2`).
+					WithArgs(uint(1), "k1", 1).
+					WillReturnRows(tt.existing)
+			}
+			if tt.takeOver >= 0 && tt.existing != nil {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "idempotency_keys" SET "locked_until"=\$1 WHERE id = \$2 AND status_code = 0 AND locked_until <= \$3`).
+					WithArgs(now.Add(time.Minute), uint(4), now).
+					WillReturnResult(sqlmock.NewResult(0, tt.takeOver))
+				mock.ExpectCommit()
+			}
+
+			record, err := service.Begin(context.Background(), 1, "k1", "abc")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantStatus, record.StatusCode)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdempotencyService_Complete(t *testing.T) {
+	lockedUntil := time.Date(2026, 10, 18, 12, 5, 0, 0, time.UTC)
+	body := []byte(`{"status":"success"}`)
+
+	for _, tt := range []struct {
+		name    string
+		rows    int64
+		wantErr error
+	}{
+		{name: "lease held", rows: 1},
+		{name: "lease taken over", rows: 0, wantErr: ErrIdempotencyLeaseLost},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupTestDB(t)
+			service := NewIdempotencyService(db, time.Hour, time.Minute)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE "idempotency_keys" SET "response_body"=\$1,"status_code"=\$2 WHERE id = \$3 AND locked_until = \$4`).
+				WithArgs(body, 200, uint(9), lockedUntil).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+			mock.ExpectCommit()
+
+			err := service.Complete(context.Background(), &models.IdempotencyKey{ID: 9, LockedUntil: lockedUntil}, 200, body)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdempotencyService_Release(t *testing.T) {
+	lockedUntil := time.Date(2026, 10, 18, 12, 5, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name    string
+		rows    int64
+		wantErr error
+	}{
+		{name: "lease held", rows: 1},
+		{name: "lease taken over", rows: 0, wantErr: ErrIdempotencyLeaseLost},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupTestDB(t)
+			service := NewIdempotencyService(db, time.Hour, time.Minute)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM "idempotency_keys" WHERE id = \$1 AND locked_until = \$2`).
+				WithArgs(uint(9), lockedUntil).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+			mock.ExpectCommit()
+
+			err := service.Release(context.Background(), &models.IdempotencyKey{ID: 9, LockedUntil: lockedUntil})
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdempotencyPurger_Purge(t *testing.T) {
+	db, mock := setupTestDB(t)
+	purger := NewIdempotencyPurger(db, time.Hour)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "idempotency_keys" WHERE expires_at <= \$1`).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	purged, err := purger.Purge(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
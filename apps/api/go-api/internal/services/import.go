@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/validator"
+	"gorm.io/gorm"
+)
+
+// ImportURLs creates links for records from another shortener, keeping their
+// short codes and creation times. Every record goes through the same checks
+// as CreateURL; records whose code is taken, including by a link in the
+// trash, are reported as conflicts. With dryRun nothing is written and the
+// report shows what an import would do.
+func (s *URLService) ImportURLs(ctx context.Context, userID uint, records []models.ImportRecord, dryRun bool) (*models.ImportReport, error) {
+	report := &models.ImportReport{DryRun: dryRun, Results: make([]models.ImportResult, 0, len(records))}
+	seen := make(map[string]bool)
+
+	for start := 0; start < len(records); start += bulkBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		batch := records[start:min(start+bulkBatchSize, len(records))]
+		taken, err := s.takenShortCodes(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range batch {
+			result, err := s.importRecord(ctx, userID, record, dryRun, seen, taken)
+			if err != nil {
+				return nil, err
+			}
+			report.Add(result)
+		}
+	}
+	return report, nil
+}
+
+func (s *URLService) importRecord(ctx context.Context, userID uint, record models.ImportRecord, dryRun bool, seen, taken map[string]bool) (models.ImportResult, error) {
+	result := models.ImportResult{Row: record.Row, ShortCode: record.ShortCode, OriginalURL: record.OriginalURL}
+	reject := func(status, reason string) (models.ImportResult, error) {
+		result.Status, result.Error = status, reason
+		return result, nil
+	}
+
+	if record.Error != "" {
+		return reject(models.ImportInvalid, record.Error)
+	}
+	req := &models.CreateURLRequest{OriginalURL: record.OriginalURL, Title: record.Title, ShortCode: record.ShortCode}
+	if err := validator.Struct(req); err != nil {
+		return reject(models.ImportInvalid, err.Error())
+	}
+	if req.ShortCode != "" {
+		if seen[req.ShortCode] {
+			return reject(models.ImportConflict, "short code appears earlier in the import")
+		}
+		seen[req.ShortCode] = true
+		if taken[req.ShortCode] {
+			return reject(models.ImportConflict, "short code is already taken")
+		}
+	}
+	if err := s.validateCreate(ctx, req); err != nil {
+		if !isRejection(err) {
+			return result, err
+		}
+		return reject(models.ImportInvalid, err.Error())
+	}
+
+	if dryRun {
+		result.Status = models.ImportReady
+		return result, nil
+	}
+
+	var createdAt time.Time
+	if record.CreatedAt != nil {
+		createdAt = *record.CreatedAt
+	}
+	var url *models.URLResponse
+	err := s.withLinkQuota(ctx, userID, func(db *gorm.DB) error {
+		var err error
+		url, err = s.insertURL(db, userID, req, createdAt)
+		return err
+	})
+	if err != nil {
+		var conflict *ShortCodeConflictError
+		if errors.As(err, &conflict) {
+			return reject(models.ImportConflict, "short code is already taken")
+		}
+		return reject(models.ImportFailed, bulkErrorReason(err))
+	}
+	result.Status = models.ImportCreated
+	result.ID = url.ID
+	result.ShortCode = url.ShortCode
+	return result, nil
+}
+
+// takenShortCodes returns which of the records' short codes are in use
+func (s *URLService) takenShortCodes(ctx context.Context, records []models.ImportRecord) (map[string]bool, error) {
+	var codes []string
+	for _, record := range records {
+		if record.ShortCode != "" {
+			codes = append(codes, record.ShortCode)
+		}
+	}
+	taken := make(map[string]bool)
+	if len(codes) == 0 {
+		return taken, nil
+	}
+
+	var existing []string
+	err := s.db.WithContext(ctx).Unscoped().Model(&models.URL{}).
+		Where("short_code IN ?", codes).
+		Pluck("short_code", &existing).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range existing {
+		taken[code] = true
+	}
+	return taken, nil
+}
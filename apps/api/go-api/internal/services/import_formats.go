@@ -0,0 +1,413 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// Formats ParseImport reads
+const (
+	// ImportFormatCSV has the columns short_code, original_url, title and
+	// created_at, in any order
+	ImportFormatCSV        = "csv"
+	ImportFormatBitly      = "bitly"
+	ImportFormatYOURLSSQL  = "yourls-sql"
+	ImportFormatYOURLSJSON = "yourls-json"
+)
+
+var (
+	ErrUnknownImportFormat = errors.New("unknown import format")
+	ErrInvalidImport       = errors.New("invalid import file")
+)
+
+// ParseImport reads the links in an export from another shortener
+func ParseImport(format string, r io.Reader) ([]models.ImportRecord, error) {
+	switch format {
+	case ImportFormatCSV:
+		return parseImportCSV(r, genericCSVColumns, false)
+	case ImportFormatBitly:
+		return parseImportCSV(r, bitlyCSVColumns, true)
+	case ImportFormatYOURLSSQL:
+		return parseYOURLSSQL(r)
+	case ImportFormatYOURLSJSON:
+		return parseYOURLSJSON(r)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownImportFormat, format)
+	}
+}
+
+// Record fields CSV columns map to
+const (
+	importShortCode   = "short_code"
+	importOriginalURL = "original_url"
+	importTitle       = "title"
+	importCreatedAt   = "created_at"
+)
+
+// genericCSVColumns and bitlyCSVColumns map header names, lowercased and
+// with spaces and underscores removed, to record fields
+var (
+	genericCSVColumns = map[string]string{
+		"shortcode":   importShortCode,
+		"originalurl": importOriginalURL,
+		"title":       importTitle,
+		"createdat":   importCreatedAt,
+	}
+	bitlyCSVColumns = map[string]string{
+		"bitlink":        importShortCode,
+		"link":           importShortCode,
+		"shortlink":      importShortCode,
+		"shorturl":       importShortCode,
+		"longurl":        importOriginalURL,
+		"destinationurl": importOriginalURL,
+		"title":          importTitle,
+		"created":        importCreatedAt,
+		"createdat":      importCreatedAt,
+		"datecreated":    importCreatedAt,
+	}
+)
+
+// parseImportCSV reads records from CSV with a header row. Columns not in
+// columns are ignored. With shortLinks the short code column holds whole
+// short links, such as bit.ly/abc123, and the code is their last segment.
+func parseImportCSV(r io.Reader, columns map[string]string, shortLinks bool) ([]models.ImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading CSV header: %v", ErrInvalidImport, err)
+	}
+	fields := make([]string, len(header))
+	hasURL := false
+	for i, name := range header {
+		name = strings.NewReplacer(" ", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		fields[i] = columns[name]
+		hasURL = hasURL || fields[i] == importOriginalURL
+	}
+	if !hasURL {
+		return nil, fmt.Errorf("%w: no destination URL column", ErrInvalidImport)
+	}
+
+	var records []models.ImportRecord
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		record := models.ImportRecord{Row: line}
+		for i, value := range values {
+			if i >= len(fields) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			switch fields[i] {
+			case importShortCode:
+				if shortLinks {
+					value = shortLinkCode(value)
+				}
+				record.ShortCode = value
+			case importOriginalURL:
+				record.OriginalURL = value
+			case importTitle:
+				record.Title = value
+			case importCreatedAt:
+				setImportCreatedAt(&record, value)
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+// shortLinkCode is the code of a short link such as https://bit.ly/abc123
+func shortLinkCode(link string) string {
+	link = strings.TrimRight(link, "/")
+	if i := strings.LastIndex(link, "/"); i >= 0 {
+		return link[i+1:]
+	}
+	return link
+}
+
+// importTimeLayouts are the timestamp formats found in exports; MySQL
+// DATETIME values carry no zone and are read as UTC
+var importTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -0700 MST",
+	time.DateOnly,
+}
+
+func setImportCreatedAt(record *models.ImportRecord, value string) {
+	if value == "" {
+		return
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.Unix(seconds, 0).UTC()
+		record.CreatedAt = &t
+		return
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			record.CreatedAt = &t
+			return
+		}
+	}
+	record.Error = fmt.Sprintf("unrecognised created_at %q", value)
+}
+
+// yourlsColumns is the column order of the YOURLS url table, used for
+// INSERT statements without a column list
+var yourlsColumns = []string{"keyword", "url", "title", "timestamp", "ip", "clicks"}
+
+// yourlsRecord builds a record from a row of the YOURLS url table
+func yourlsRecord(row int, values map[string]string) models.ImportRecord {
+	record := models.ImportRecord{
+		Row:         row,
+		ShortCode:   values["keyword"],
+		OriginalURL: values["url"],
+		Title:       values["title"],
+	}
+	setImportCreatedAt(&record, values["timestamp"])
+	return record
+}
+
+// isYOURLSTable reports whether name is the YOURLS url table, which is
+// named url after the table prefix, yourls_ by default
+func isYOURLSTable(name string) bool {
+	name = strings.ToLower(name)
+	return name == "url" || strings.HasSuffix(name, "_url")
+}
+
+var insertStatement = regexp.MustCompile("(?i)INSERT\\s+(?:IGNORE\\s+)?INTO\\s+`?(\\w+)`?\\s*")
+
+// parseYOURLSSQL reads the rows of INSERT statements into the YOURLS url
+// table from a mysqldump, ignoring every other statement
+func parseYOURLSSQL(r io.Reader) ([]models.ImportRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []models.ImportRecord
+	scanner := &sqlScanner{s: string(data)}
+	for {
+		match := insertStatement.FindStringSubmatchIndex(scanner.s[scanner.pos:])
+		if match == nil {
+			return records, nil
+		}
+		table := scanner.s[scanner.pos+match[2] : scanner.pos+match[3]]
+		scanner.pos += match[1]
+		if !isYOURLSTable(table) {
+			continue
+		}
+
+		columns := yourlsColumns
+		if scanner.peek() == '(' {
+			if columns, err = scanner.tuple(); err != nil {
+				return nil, err
+			}
+			for i, column := range columns {
+				columns[i] = strings.ToLower(strings.Trim(column, "`"))
+			}
+		}
+		if !scanner.keyword("VALUES") {
+			return nil, fmt.Errorf("%w: expected VALUES at offset %d", ErrInvalidImport, scanner.pos)
+		}
+
+		for {
+			values, err := scanner.tuple()
+			if err != nil {
+				return nil, err
+			}
+			row := make(map[string]string, len(columns))
+			for i, value := range values {
+				if i < len(columns) {
+					row[columns[i]] = value
+				}
+			}
+			records = append(records, yourlsRecord(len(records)+1, row))
+
+			if scanner.peek() != ',' {
+				break
+			}
+			scanner.pos++
+		}
+	}
+}
+
+// sqlScanner reads the value lists of MySQL INSERT statements
+type sqlScanner struct {
+	s   string
+	pos int
+}
+
+// peek skips whitespace and returns the next byte, or 0 at the end
+func (p *sqlScanner) peek() byte {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	if p.pos == len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *sqlScanner) keyword(word string) bool {
+	p.peek()
+	if len(p.s)-p.pos < len(word) || !strings.EqualFold(p.s[p.pos:p.pos+len(word)], word) {
+		return false
+	}
+	p.pos += len(word)
+	return true
+}
+
+// tuple reads a parenthesized, comma separated list of values. NULL reads
+// as an empty string.
+func (p *sqlScanner) tuple() ([]string, error) {
+	if p.peek() != '(' {
+		return nil, fmt.Errorf("%w: expected ( at offset %d", ErrInvalidImport, p.pos)
+	}
+	p.pos++
+
+	var values []string
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return values, nil
+		default:
+			return nil, fmt.Errorf("%w: unterminated value list at offset %d", ErrInvalidImport, p.pos)
+		}
+	}
+}
+
+func (p *sqlScanner) value() (string, error) {
+	if p.peek() != '\'' {
+		start := p.pos
+		for p.pos < len(p.s) && p.s[p.pos] != ',' && p.s[p.pos] != ')' {
+			p.pos++
+		}
+		value := strings.TrimSpace(p.s[start:p.pos])
+		if strings.EqualFold(value, "NULL") {
+			return "", nil
+		}
+		return value, nil
+	}
+
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s):
+			p.pos++
+			b.WriteByte(unescapeMySQL(p.s[p.pos]))
+		case c == '\'' && p.pos+1 < len(p.s) && p.s[p.pos+1] == '\'':
+			p.pos++
+			b.WriteByte('\'')
+		case c == '\'':
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("%w: unterminated string", ErrInvalidImport)
+}
+
+func unescapeMySQL(c byte) byte {
+	switch c {
+	case '0':
+		return 0
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'Z':
+		return 0x1a
+	default:
+		return c
+	}
+}
+
+// parseYOURLSJSON reads a JSON dump of the YOURLS url table: either the
+// phpMyAdmin export, whose table objects hold the rows under "data", or a
+// plain array of rows
+func parseYOURLSJSON(r io.Reader) ([]models.ImportRecord, error) {
+	var items []map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	var records []models.ImportRecord
+	add := func(raw map[string]json.RawMessage) {
+		row := make(map[string]string, len(raw))
+		for key, value := range raw {
+			row[strings.ToLower(key)] = jsonScalar(value)
+		}
+		records = append(records, yourlsRecord(len(records)+1, row))
+	}
+
+	for _, item := range items {
+		if _, ok := item["keyword"]; ok {
+			add(item)
+			continue
+		}
+		if jsonScalar(item["type"]) != "table" || !isYOURLSTable(jsonScalar(item["name"])) {
+			continue
+		}
+		var rows []map[string]json.RawMessage
+		if err := json.Unmarshal(item["data"], &rows); err != nil {
+			return nil, fmt.Errorf("%w: table data: %v", ErrInvalidImport, err)
+		}
+		for _, row := range rows {
+			add(row)
+		}
+	}
+	return records, nil
+}
+
+// jsonScalar renders a JSON string, number or boolean as text, and anything
+// else, including null, as an empty string
+func jsonScalar(raw json.RawMessage) string {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
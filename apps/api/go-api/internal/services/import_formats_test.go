@@ -0,0 +1,117 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestParseImport(t *testing.T) {
+	created := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		want    []models.ImportRecord
+		wantErr error
+	}{
+		{
+			name:   "generic csv",
+			format: ImportFormatCSV,
+			input:  "original_url,short_code,title,created_at\nhttps://example.com,abc,Example,2024-03-04T05:06:07Z\nhttps://other.example,,,\n",
+			want: []models.ImportRecord{
+				{Row: 2, ShortCode: "abc", OriginalURL: "https://example.com", Title: "Example", CreatedAt: &created},
+				{Row: 3, OriginalURL: "https://other.example"},
+			},
+		},
+		{
+			name:   "csv with bad date",
+			format: ImportFormatCSV,
+			input:  "short_code,original_url,created_at\nabc,https://example.com,yesterday\n",
+			want: []models.ImportRecord{
+				{Row: 2, ShortCode: "abc", OriginalURL: "https://example.com", Error: `unrecognised created_at "yesterday"`},
+			},
+		},
+		{
+			name:   "bitly csv",
+			format: ImportFormatBitly,
+			input:  "Title,Bitlink,Long URL,Created,Clicks\nExample,bit.ly/abc123,https://example.com,2024-03-04 05:06:07,12\n",
+			want: []models.ImportRecord{
+				{Row: 2, ShortCode: "abc123", OriginalURL: "https://example.com", Title: "Example", CreatedAt: &created},
+			},
+		},
+		{
+			name:   "yourls sql",
+			format: ImportFormatYOURLSSQL,
+			input: "CREATE TABLE `yourls_url` (`keyword` varchar(100));\n" +
+				"INSERT INTO `yourls_options` VALUES (1,'version','1.9');\n" +
+				"INSERT INTO `yourls_url` (`keyword`, `url`, `title`, `timestamp`, `ip`, `clicks`) VALUES " +
+				"('abc','https://example.com','It\\'s here','2024-03-04 05:06:07','127.0.0.1',3)," +
+				"('x','https://other.example/?a=1,2',NULL,'2024-03-04 05:06:07','127.0.0.1',0);\n",
+			want: []models.ImportRecord{
+				{Row: 1, ShortCode: "abc", OriginalURL: "https://example.com", Title: "It's here", CreatedAt: &created},
+				{Row: 2, ShortCode: "x", OriginalURL: "https://other.example/?a=1,2", CreatedAt: &created},
+			},
+		},
+		{
+			name:   "yourls sql without column list",
+			format: ImportFormatYOURLSSQL,
+			input:  "INSERT INTO yourls_url VALUES ('abc','https://example.com','','2024-03-04 05:06:07','127.0.0.1',3);",
+			want: []models.ImportRecord{
+				{Row: 1, ShortCode: "abc", OriginalURL: "https://example.com", CreatedAt: &created},
+			},
+		},
+		{
+			name:    "yourls sql truncated",
+			format:  ImportFormatYOURLSSQL,
+			input:   "INSERT INTO `yourls_url` VALUES ('abc','https://exa",
+			wantErr: ErrInvalidImport,
+		},
+		{
+			name:   "yourls phpmyadmin json",
+			format: ImportFormatYOURLSJSON,
+			input: `[{"type":"header","version":"5.2"},{"type":"table","name":"yourls_url","data":[` +
+				`{"keyword":"abc","url":"https://example.com","title":"Example","timestamp":"2024-03-04 05:06:07","clicks":"3"}]}]`,
+			want: []models.ImportRecord{
+				{Row: 1, ShortCode: "abc", OriginalURL: "https://example.com", Title: "Example", CreatedAt: &created},
+			},
+		},
+		{
+			name:   "yourls json rows",
+			format: ImportFormatYOURLSJSON,
+			input:  `[{"keyword":"abc","url":"https://example.com","title":null,"timestamp":1709528767}]`,
+			want: []models.ImportRecord{
+				{Row: 1, ShortCode: "abc", OriginalURL: "https://example.com", CreatedAt: &created},
+			},
+		},
+		{
+			name:    "unknown format",
+			format:  "tinyurl",
+			wantErr: ErrUnknownImportFormat,
+		},
+		{
+			name:    "csv without destination",
+			format:  ImportFormatCSV,
+			input:   "short_code,title\nabc,Example\n",
+			wantErr: ErrInvalidImport,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseImport(tt.format, strings.NewReader(tt.input))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestURLService_ImportURLs(t *testing.T) {
+	created := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	records := []models.ImportRecord{
+		{Row: 2, OriginalURL: "not a url"},
+		{Row: 3, ShortCode: "taken1", OriginalURL: "https://example.com"},
+		{Row: 4, ShortCode: "fresh1", OriginalURL: "https://example.com", Title: "Example", CreatedAt: &created},
+		{Row: 5, ShortCode: "fresh1", OriginalURL: "https://example.org"},
+		{Row: 6, ShortCode: "admin", OriginalURL: "https://example.com"},
+		{Row: 7, ShortCode: "late1", OriginalURL: "https://example.com", Error: "unrecognised created_at"},
+	}
+	expectTaken := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT "short_code" FROM "urls" WHERE short_code IN \(\$1,\$2,\$3,\$4,\$5\)$`).
+			WithArgs("taken1", "fresh1", "fresh1", "admin", "late1").
+			WillReturnRows(sqlmock.NewRows([]string{"short_code"}).AddRow("taken1"))
+	}
+
+	tests := []struct {
+		name       string
+		dryRun     bool
+		mock       func(sqlmock.Sqlmock)
+		wantStatus []string
+	}{
+		{
+			name:   "dry run",
+			dryRun: true,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectTaken(mock)
+				expectDestinationAllowed(mock)
+			},
+			wantStatus: []string{models.ImportInvalid, models.ImportConflict, models.ImportReady, models.ImportConflict, models.ImportInvalid, models.ImportInvalid},
+		},
+		{
+			name: "commit keeps the creation time",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectTaken(mock)
+				expectDestinationAllowed(mock)
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "urls"`).
+					WithArgs("https://example.com", "fresh1", "Example", uint(1), 0, created, sqlmock.AnyArg(), nil, nil, "", false, "", 0, int64(1), nil, nil, nil, "").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+				mock.ExpectCommit()
+			},
+			wantStatus: []string{models.ImportInvalid, models.ImportConflict, models.ImportCreated, models.ImportConflict, models.ImportInvalid, models.ImportInvalid},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupTestDB(t)
+			service := NewURLService(db)
+			tt.mock(mock)
+
+			report, err := service.ImportURLs(context.Background(), 1, records, tt.dryRun)
+			require.NoError(t, err)
+
+			var statuses []string
+			for _, result := range report.Results {
+				statuses = append(statuses, result.Status)
+			}
+			assert.Equal(t, tt.wantStatus, statuses)
+			assert.Equal(t, tt.dryRun, report.DryRun)
+			assert.Equal(t, 1, report.Imported)
+			assert.Equal(t, 2, report.Conflicts)
+			assert.Equal(t, 3, report.Invalid)
+			assert.Equal(t, "short code appears earlier in the import", report.Results[3].Error)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultUnlockTTL           = time.Hour
+	DefaultMaxPasswordAttempts = 5
+	DefaultPasswordLockout     = 15 * time.Minute
+	// DefaultMaxLinkPasswordAttempts bounds the wrong passwords one link
+	// answers per lockout window, from every visitor together. It is well
+	// above what the visitors of a link get wrong by mistake.
+	DefaultMaxLinkPasswordAttempts = 1000
+)
+
+var (
+	ErrInvalidLinkPassword = errors.New("invalid link password")
+	ErrTooManyAttempts     = errors.New("too many attempts")
+)
+
+// LinkLock issues and checks the signed tokens that let a visitor through a
+// password-protected link, and limits failed unlock attempts per visitor and
+// per link
+type LinkLock struct {
+	secret      []byte
+	ttl         time.Duration
+	limiter     *AttemptLimiter
+	linkLimiter *AttemptLimiter
+}
+
+// NewLinkLock returns a LinkLock signing tokens with secret. An empty secret
+// is replaced by a random one, so tokens do not survive a restart. limiter
+// counts attempts per visitor and link and is the main control; linkLimiter
+// counts wrong passwords per link alone, for visitors who can change their
+// address. It never turns away the right password.
+func NewLinkLock(secret string, ttl time.Duration, limiter, linkLimiter *AttemptLimiter) *LinkLock {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate link lock secret: %v", err))
+		}
+	}
+	if ttl <= 0 {
+		ttl = DefaultUnlockTTL
+	}
+	if limiter == nil {
+		limiter = NewAttemptLimiter(DefaultMaxPasswordAttempts, DefaultPasswordLockout)
+	}
+	if linkLimiter == nil {
+		linkLimiter = NewAttemptLimiter(DefaultMaxLinkPasswordAttempts, DefaultPasswordLockout)
+	}
+
+	return &LinkLock{
+		secret:      key,
+		ttl:         ttl,
+		limiter:     limiter,
+		linkLimiter: linkLimiter,
+	}
+}
+
+// TTL is how long an unlock token stays valid
+func (l *LinkLock) TTL() time.Duration {
+	return l.ttl
+}
+
+// Sign returns an unlock token for the URL with the given ID and password
+// fingerprint
+func (l *LinkLock) Sign(urlID uint, fingerprint string, now time.Time) string {
+	payload := fmt.Sprintf("%d.%d", urlID, now.Add(l.ttl).Unix())
+	return payload + "." + l.mac(payload, fingerprint)
+}
+
+// Verify reports whether token unlocks the URL with the given ID while its
+// password has the given fingerprint
+func (l *LinkLock) Verify(token string, urlID uint, fingerprint string, now time.Time) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(l.mac(payload, fingerprint))) {
+		return false
+	}
+	if parts[0] != strconv.FormatUint(uint64(urlID), 10) {
+		return false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	return err == nil && now.Unix() < expires
+}
+
+// mac signs payload for one password of the link. The prefix keeps unlock
+// tokens apart from anything else signed with the same secret.
+func (l *LinkLock) mac(payload, fingerprint string) string {
+	h := hmac.New(sha256.New, l.secret)
+	h.Write([]byte("unlock-link." + payload + "." + fingerprint))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// passwordFingerprint identifies a link password by its hash without
+// handing the hash itself around; it is empty for links without one
+func passwordFingerprint(hash string) string {
+	if hash == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(hash))
+	return hex.EncodeToString(sum[:16])
+}
+
+// UnlockURL checks password against the link and returns an unlock token.
+// clientKey identifies the visitor for rate limiting failed attempts.
+func (s *URLService) UnlockURL(ctx context.Context, shortCode, password, clientKey string) (*models.URLResponse, string, error) {
+	key := clientKey + "|" + shortCode
+	if !s.lock.limiter.Allow(key) {
+		return nil, "", ErrTooManyAttempts
+	}
+
+	var url models.URL
+	if err := s.db.Where("short_code = ?", shortCode).First(&url).Error; err != nil {
+		// Only wrong passwords count against the limit
+		s.lock.limiter.Release(key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrURLNotFound
+		}
+		return nil, "", err
+	}
+
+	if url.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(url.Password), []byte(password)); err != nil {
+			// Once the link has had too many wrong passwords, the visitors
+			// who keep guessing are told to slow down as well
+			if !s.lock.linkLimiter.Allow(shortCode) {
+				return nil, "", ErrTooManyAttempts
+			}
+			return nil, "", ErrInvalidLinkPassword
+		}
+	}
+
+	s.lock.limiter.Reset(key)
+	resp := toURLResponse(&url)
+	return resp, s.lock.Sign(url.ID, resp.PasswordFingerprint, time.Now()), nil
+}
+
+// VerifyUnlockToken reports whether token lets the visitor through url
+func (s *URLService) VerifyUnlockToken(url *models.URLResponse, token string) bool {
+	return s.lock.Verify(token, url.ID, url.PasswordFingerprint, time.Now())
+}
+
+// UnlockTTL is how long an unlock token issued by UnlockURL stays valid
+func (s *URLService) UnlockTTL() time.Duration {
+	return s.lock.TTL()
+}
+
+// hashLinkPassword hashes a link password the same way user passwords are
+func hashLinkPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// AttemptLimiter blocks a key after too many attempts within a window
+type AttemptLimiter struct {
+	max    int
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	attempts  map[string]*attemptWindow
+	lastPrune time.Time
+}
+
+type attemptWindow struct {
+	count int
+	start time.Time
+}
+
+func NewAttemptLimiter(max int, window time.Duration) *AttemptLimiter {
+	if max <= 0 {
+		max = DefaultMaxPasswordAttempts
+	}
+	if window <= 0 {
+		window = DefaultPasswordLockout
+	}
+	return &AttemptLimiter{
+		max:      max,
+		window:   window,
+		now:      time.Now,
+		attempts: make(map[string]*attemptWindow),
+	}
+}
+
+// Allow reserves an attempt for key and reports whether it is within the
+// limit. Checking and counting happen under one lock, so parallel attempts
+// cannot all pass before any of them is counted. Attempts that succeed can
+// be handed back with Release or Reset.
+func (l *AttemptLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	w, ok := l.attempts[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &attemptWindow{start: now}
+		l.attempts[key] = w
+	}
+	if w.count >= l.max {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// Release hands back one attempt reserved by Allow
+func (l *AttemptLimiter) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if w, ok := l.attempts[key]; ok && w.count > 0 {
+		w.count--
+	}
+}
+
+// Reset forgets the attempts recorded for key
+func (l *AttemptLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, key)
+}
+
+// prune drops windows that have run out, keeping the map bounded by the
+// number of keys tried recently. It runs at most once per window.
+func (l *AttemptLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	l.lastPrune = now
+
+	for key, w := range l.attempts {
+		if now.Sub(w.start) >= l.window {
+			delete(l.attempts, key)
+		}
+	}
+}
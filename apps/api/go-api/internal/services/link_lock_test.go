@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLinkLock_SignAndVerify(t *testing.T) {
+	now := time.Now()
+	lock := NewLinkLock("secret", time.Hour, nil, nil)
+	token := lock.Sign(7, "fp", now)
+
+	assert.True(t, lock.Verify(token, 7, "fp", now))
+	assert.False(t, lock.Verify(token, 8, "fp", now), "token is bound to one link")
+	assert.False(t, lock.Verify(token, 7, "other", now), "token is bound to one password")
+	assert.False(t, lock.Verify(token, 7, "fp", now.Add(2*time.Hour)), "token expires")
+	assert.False(t, lock.Verify(token+"0", 7, "fp", now), "tampered signature")
+	assert.False(t, NewLinkLock("other", time.Hour, nil, nil).Verify(token, 7, "fp", now), "different secret")
+	assert.False(t, lock.Verify("garbage", 7, "fp", now))
+}
+
+func TestAttemptLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := NewAttemptLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	assert.True(t, limiter.Allow("a"))
+	assert.True(t, limiter.Allow("a"))
+	assert.False(t, limiter.Allow("a"))
+	assert.True(t, limiter.Allow("b"), "keys are limited independently")
+
+	now = now.Add(2 * time.Minute)
+	assert.True(t, limiter.Allow("a"), "window has passed")
+
+	limiter.Release("a")
+	assert.True(t, limiter.Allow("a"))
+	assert.True(t, limiter.Allow("a"), "released attempt is handed back")
+	assert.False(t, limiter.Allow("a"))
+
+	limiter.Reset("a")
+	assert.True(t, limiter.Allow("a"))
+}
+
+func TestAttemptLimiter_ConcurrentAllow(t *testing.T) {
+	limiter := NewAttemptLimiter(5, time.Minute)
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.Allow("a") {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(5), allowed.Load(), "parallel attempts are reserved one by one")
+}
+
+func TestURLService_UnlockURL(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	urlRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "original_url", "short_code", "owner", "password_hash"}).
+			AddRow(7, "https://example.com/docs", "docs", 1, string(hashed))
+	}
+
+	db, mock := setupTestDB(t)
+	lock := NewLinkLock("secret", time.Hour, NewAttemptLimiter(1, time.Minute), nil)
+	service := NewURLService(db, WithLinkLock(lock))
+
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE short_code = \$1`).
+		WithArgs("docs", 1).
+		WillReturnRows(urlRows())
+	_, _, err = service.UnlockURL(context.Background(), "docs", "wrong", "192.0.2.1")
+	assert.ErrorIs(t, err, ErrInvalidLinkPassword)
+
+	// One failure is the limit, so the right password is refused as well
+	_, _, err = service.UnlockURL(context.Background(), "docs", "secret", "192.0.2.1")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE short_code = \$1`).
+		WithArgs("docs", 1).
+		WillReturnRows(urlRows())
+	url, token, err := service.UnlockURL(context.Background(), "docs", "secret", "198.51.100.1")
+	require.NoError(t, err)
+	assert.True(t, url.PasswordProtected)
+	assert.True(t, service.VerifyUnlockToken(url, token))
+
+	rehashed, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	url.PasswordFingerprint = passwordFingerprint(string(rehashed))
+	assert.False(t, service.VerifyUnlockToken(url, token), "setting the password again signs visitors out")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestURLService_UnlockURL_PerLinkLimit(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	db, mock := setupTestDB(t)
+	lock := NewLinkLock("secret", time.Hour, NewAttemptLimiter(5, time.Minute), NewAttemptLimiter(2, time.Minute))
+	service := NewURLService(db, WithLinkLock(lock))
+
+	// Every guess comes from a different address, as when the attacker
+	// rotates them, but the link still counts them together
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT \* FROM "urls" WHERE short_code = \$1`).
+			WithArgs("docs", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "short_code", "password_hash"}).
+				AddRow(7, "docs", string(hashed)))
+		_, _, err = service.UnlockURL(context.Background(), "docs", "wrong", fmt.Sprintf("198.51.100.%d", i))
+		assert.ErrorIs(t, err, ErrInvalidLinkPassword)
+	}
+
+	// Further wrong guesses are refused as too many...
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE short_code = \$1`).
+		WithArgs("docs", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_code", "password_hash"}).
+			AddRow(7, "docs", string(hashed)))
+	_, _, err = service.UnlockURL(context.Background(), "docs", "wrong", "198.51.100.98")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	// ...but visitors who know the password still get in
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE short_code = \$1`).
+		WithArgs("docs", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_code", "password_hash"}).
+			AddRow(7, "docs", string(hashed)))
+	_, token, err := service.UnlockURL(context.Background(), "docs", "secret", "198.51.100.99")
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
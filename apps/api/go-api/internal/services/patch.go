@@ -0,0 +1,95 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/validator"
+)
+
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// PatchURL applies a JSON merge patch (RFC 7396) to the editable fields of a
+// URL. Members left out of patch keep their value, null clears a field, and
+// "password": null removes the link password. A non-zero version must match
+// the stored one or ErrVersionMismatch is returned.
+func (s *URLService) PatchURL(ctx context.Context, userID uint, id uint, patch []byte, version int64) (*models.URLResponse, error) {
+	var changes map[string]interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil || changes == nil {
+		return nil, fmt.Errorf("%w: body must be a JSON object", ErrInvalidPatch)
+	}
+
+	url, err := s.ownedURL(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := toMap(&models.UpdateURLRequest{
+		OriginalURL:    url.OriginalURL,
+		Title:          url.Title,
+		ShortCode:      url.ShortCode,
+		ExpiresAt:      url.ExpiresAt,
+		MaxClicks:      url.MaxClicks,
+		FallbackURL:    url.FallbackURL,
+		RedirectStatus: url.RedirectStatus,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged := mergePatch(current, changes).(map[string]interface{})
+	if value, ok := changes["password"]; ok && value == nil {
+		merged["password"] = ""
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var req models.UpdateURLRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if err := validator.Struct(&req); err != nil {
+		return nil, err
+	}
+
+	return s.applyUpdate(ctx, userID, url, &req, version, false)
+}
+
+// mergePatch applies patch to target as RFC 7396 describes
+func mergePatch(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result, ok := target.(map[string]interface{})
+	if !ok {
+		result = make(map[string]interface{})
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = mergePatch(result[key], value)
+	}
+	return result
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/validator"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]interface{}{
+		"title": "Old",
+		"meta":  map[string]interface{}{"a": "1", "b": "2"},
+		"keep":  true,
+	}
+	patch := map[string]interface{}{
+		"title": "New",
+		"meta":  map[string]interface{}{"a": nil, "c": "3"},
+		"gone":  nil,
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"title": "New",
+		"meta":  map[string]interface{}{"b": "2", "c": "3"},
+		"keep":  true,
+	}, mergePatch(target, patch))
+	assert.Equal(t, "scalar", mergePatch(target, "scalar"))
+}
+
+func expectOwnedURL(mock sqlmock.Sqlmock, version int64) {
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE \(id = \$1 AND owner = \$2\) AND "urls"\."deleted_at" IS NULL`).
+		WithArgs(uint(7), uint(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "original_url", "short_code", "title", "owner", "clicks", "created_at", "clicks_at", "version"}).
+			AddRow(7, "https://example.com", "abc123", "Old title", 1, 12, created, created, version))
+}
+
+func TestURLService_PatchURL(t *testing.T) {
+	t.Run("changes only the given fields", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		expectOwnedURL(mock, 3)
+		expectDestinationAllowed(mock)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "urls" SET "original_url"=\$1,"short_code"=\$2,"title"=\$3,.*"version"=\$10 WHERE version = \$11 AND "urls"\."deleted_at" IS NULL AND "id" = \$12`).
+			WithArgs("https://example.com", "abc123", "New title", nil, nil, "", false, "", 0, int64(4), int64(3), uint(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO "url_revisions"`).
+			WithArgs(uint(7), int64(4), uint(1), "title", "Old title", "New title", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		url, err := service.PatchURL(context.Background(), 1, 7, []byte(`{"title":"New title"}`), 3)
+		require.NoError(t, err)
+		assert.Equal(t, "New title", url.Title)
+		assert.Equal(t, "abc123", url.ShortCode)
+		assert.Equal(t, int64(12), url.Clicks)
+		assert.Equal(t, int64(4), url.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("title of an expired link", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		expiresAt := time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(`SELECT \* FROM "urls" WHERE \(id = \$1 AND owner = \$2\)`).
+			WithArgs(uint(7), uint(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "original_url", "short_code", "title", "owner", "clicks", "created_at", "clicks_at", "version", "expires_at", "max_clicks", "expired"}).
+				AddRow(7, "https://example.com", "abc123", "Old title", 1, 12, created, created, 3, expiresAt, 12, true))
+		expectDestinationAllowed(mock)
+		mock.ExpectBegin()
+		// The past expiry date and the reached click limit are kept as they
+		// are, and the link stays expired
+		mock.ExpectExec(`UPDATE "urls" SET`).
+			WithArgs("https://example.com", "abc123", "New title", expiresAt, int64(12), "", true, "", 0, int64(4), int64(3), uint(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO "url_revisions"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		url, err := service.PatchURL(context.Background(), 1, 7, []byte(`{"title":"New title"}`), 0)
+		require.NoError(t, err)
+		assert.Equal(t, "New title", url.Title)
+		assert.True(t, url.Expired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("moving expiry into the past", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		expectOwnedURL(mock, 3)
+
+		_, err := service.PatchURL(context.Background(), 1, 7, []byte(`{"expires_at":"2020-01-01T00:00:00Z"}`), 0)
+		assert.ErrorIs(t, err, ErrInvalidExpiry)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		expectOwnedURL(mock, 4)
+
+		_, err := service.PatchURL(context.Background(), 1, 7, []byte(`{"title":"New title"}`), 3)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("concurrent edit", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		expectOwnedURL(mock, 3)
+		expectDestinationAllowed(mock)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "urls"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := service.PatchURL(context.Background(), 1, 7, []byte(`{"title":"New title"}`), 0)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("null clears a required field", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		expectOwnedURL(mock, 3)
+
+		_, err := service.PatchURL(context.Background(), 1, 7, []byte(`{"original_url":null}`), 0)
+		var fields validator.ValidationErrors
+		require.ErrorAs(t, err, &fields)
+		assert.Equal(t, "original_url", fields[0].Field)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		_, err := service.PatchURL(context.Background(), 1, 7, []byte(`["title"]`), 0)
+		assert.ErrorIs(t, err, ErrInvalidPatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		expectOwnedURL(mock, 3)
+
+		_, err := service.PatchURL(context.Background(), 1, 7, []byte(`{"clicks":0}`), 0)
+		assert.ErrorIs(t, err, ErrInvalidPatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// DefaultRedirectStatus is used when neither the link nor the deployment
+// picks one. A temporary redirect keeps browsers coming back, so every visit
+// is counted and destination changes take effect immediately.
+const DefaultRedirectStatus = http.StatusFound
+
+var ErrInvalidRedirectStatus = errors.New("redirect_status must be one of 301, 302, 307 or 308")
+
+// ValidRedirectStatus reports whether status may be used for redirects
+func ValidRedirectStatus(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// validateRedirectStatus accepts 0, meaning the deployment default, or any
+// supported redirect status
+func validateRedirectStatus(status int) error {
+	if status != 0 && !ValidRedirectStatus(status) {
+		return ErrInvalidRedirectStatus
+	}
+	return nil
+}
+
+// RedirectStatus returns the status code visitors of url are redirected with
+func (s *URLService) RedirectStatus(url *models.URLResponse) int {
+	if url.RedirectStatus != 0 {
+		return url.RedirectStatus
+	}
+	return s.redirectStatus
+}
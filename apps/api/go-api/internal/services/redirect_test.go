@@ -0,0 +1,41 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestURLService_RedirectStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultStatus int
+		linkStatus    int
+		want          int
+	}{
+		{name: "built-in default", defaultStatus: 0, linkStatus: 0, want: http.StatusFound},
+		{name: "deployment default", defaultStatus: http.StatusMovedPermanently, linkStatus: 0, want: http.StatusMovedPermanently},
+		{name: "invalid deployment default is ignored", defaultStatus: http.StatusOK, linkStatus: 0, want: http.StatusFound},
+		{name: "link overrides deployment", defaultStatus: http.StatusMovedPermanently, linkStatus: http.StatusTemporaryRedirect, want: http.StatusTemporaryRedirect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewURLService(nil, WithDefaultRedirectStatus(tt.defaultStatus))
+			got := service.RedirectStatus(&models.URLResponse{RedirectStatus: tt.linkStatus})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateRedirectStatus(t *testing.T) {
+	for _, status := range []int{0, 301, 302, 307, 308} {
+		assert.NoError(t, validateRedirectStatus(status), status)
+	}
+	for _, status := range []int{200, 303, 304, 404} {
+		assert.ErrorIs(t, validateRedirectStatus(status), ErrInvalidRedirectStatus, status)
+	}
+}
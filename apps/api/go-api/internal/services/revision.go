@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// revisionFields are the URL fields whose changes are recorded, in the order
+// they are reported. password_protected is recorded for the audit trail only;
+// password hashes are never stored and restoring a revision keeps the
+// current password.
+var revisionFields = []string{
+	"original_url",
+	"title",
+	"short_code",
+	"expires_at",
+	"max_clicks",
+	"fallback_url",
+	"redirect_status",
+	"password_protected",
+}
+
+// revisionValues renders the recorded fields of url as text
+func revisionValues(url *models.URL) map[string]*string {
+	text := func(s string) *string { return &s }
+
+	values := map[string]*string{
+		"original_url":       text(url.OriginalURL),
+		"title":              text(url.Title),
+		"short_code":         text(url.ShortCode),
+		"fallback_url":       text(url.FallbackURL),
+		"redirect_status":    text(strconv.Itoa(url.RedirectStatus)),
+		"password_protected": text(strconv.FormatBool(url.Password != "")),
+		"expires_at":         nil,
+		"max_clicks":         nil,
+	}
+	if url.ExpiresAt != nil {
+		values["expires_at"] = text(url.ExpiresAt.UTC().Format(time.RFC3339Nano))
+	}
+	if url.MaxClicks != nil {
+		values["max_clicks"] = text(strconv.FormatInt(*url.MaxClicks, 10))
+	}
+	return values
+}
+
+// diffRevision returns a URLRevision for every recorded field that differs
+// between before and after
+func diffRevision(url *models.URL, userID uint, before, after map[string]*string) []models.URLRevision {
+	var revisions []models.URLRevision
+	for _, field := range revisionFields {
+		from, to := before[field], after[field]
+		if (from == nil) == (to == nil) && (from == nil || *from == *to) {
+			continue
+		}
+		revisions = append(revisions, models.URLRevision{
+			URLID:    url.ID,
+			Revision: url.Version,
+			UserID:   &userID,
+			Field:    field,
+			OldValue: from,
+			NewValue: to,
+		})
+	}
+	return revisions
+}
+
+// GetURLHistory returns the edits made to a URL, newest first. Only changes
+// to revisionFields made through an update or a revision restore are
+// recorded; moving the link to and from the trash and an admin disabling or
+// enabling it leave no entry.
+func (s *URLService) GetURLHistory(ctx context.Context, userID uint, id uint) ([]models.URLRevisionResponse, error) {
+	if _, err := s.ownedURL(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	var rows []models.URLRevision
+	err := s.db.WithContext(ctx).
+		Where("url_id = ?", id).
+		Order("revision DESC, id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	history := []models.URLRevisionResponse{}
+	for _, row := range rows {
+		if n := len(history); n == 0 || history[n-1].Revision != row.Revision {
+			history = append(history, models.URLRevisionResponse{
+				Revision:  row.Revision,
+				UserID:    row.UserID,
+				CreatedAt: row.CreatedAt,
+			})
+		}
+		last := &history[len(history)-1]
+		last.Changes = append(last.Changes, models.FieldChange{
+			Field:    row.Field,
+			OldValue: row.OldValue,
+			NewValue: row.NewValue,
+		})
+	}
+	return history, nil
+}
+
+// RestoreRevision puts a URL back the way it was at the given revision, where
+// revision 1 is the URL as created. The rollback is itself recorded as a new
+// revision.
+func (s *URLService) RestoreRevision(ctx context.Context, userID uint, id uint, revision int64) (*models.URLResponse, error) {
+	url, err := s.ownedURL(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if revision < 1 || revision > url.Version {
+		return nil, ErrRevisionNotFound
+	}
+
+	// Undo every recorded change made after the revision, newest first
+	var later []models.URLRevision
+	err = s.db.WithContext(ctx).
+		Where("url_id = ? AND revision > ?", id, revision).
+		Order("revision DESC, id DESC").
+		Find(&later).Error
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.UpdateURLRequest{
+		OriginalURL:    url.OriginalURL,
+		Title:          url.Title,
+		ShortCode:      url.ShortCode,
+		ExpiresAt:      url.ExpiresAt,
+		MaxClicks:      url.MaxClicks,
+		FallbackURL:    url.FallbackURL,
+		RedirectStatus: url.RedirectStatus,
+	}
+	for _, change := range later {
+		if err := setRevisionValue(req, change.Field, change.OldValue); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.applyUpdate(ctx, userID, url, req, 0, true)
+}
+
+// setRevisionValue sets field of req from its recorded text value
+func setRevisionValue(req *models.UpdateURLRequest, field string, value *string) error {
+	text := ""
+	if value != nil {
+		text = *value
+	}
+
+	switch field {
+	case "original_url":
+		req.OriginalURL = text
+	case "title":
+		req.Title = text
+	case "short_code":
+		req.ShortCode = text
+	case "fallback_url":
+		req.FallbackURL = text
+	case "expires_at":
+		req.ExpiresAt = nil
+		if value != nil {
+			t, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return fmt.Errorf("revision has invalid %s %q: %w", field, text, err)
+			}
+			req.ExpiresAt = &t
+		}
+	case "max_clicks":
+		req.MaxClicks = nil
+		if value != nil {
+			n, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return fmt.Errorf("revision has invalid %s %q: %w", field, text, err)
+			}
+			req.MaxClicks = &n
+		}
+	case "redirect_status":
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("revision has invalid %s %q: %w", field, text, err)
+		}
+		req.RedirectStatus = n
+	}
+	return nil
+}
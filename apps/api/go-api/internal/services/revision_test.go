@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestDiffRevision(t *testing.T) {
+	expires := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	before := &models.URL{ID: 7, OriginalURL: "https://old.example.com", Title: "Same", ShortCode: "abc123"}
+	after := *before
+	after.OriginalURL = "https://new.example.com"
+	after.ExpiresAt = &expires
+	after.Password = "hash"
+	after.Version = 2
+
+	revisions := diffRevision(&after, 1, revisionValues(before), revisionValues(&after))
+	require.Len(t, revisions, 3)
+
+	assert.Equal(t, "original_url", revisions[0].Field)
+	assert.Equal(t, "https://old.example.com", *revisions[0].OldValue)
+	assert.Equal(t, "https://new.example.com", *revisions[0].NewValue)
+	assert.Equal(t, "expires_at", revisions[1].Field)
+	assert.Nil(t, revisions[1].OldValue)
+	assert.Equal(t, "2026-12-31T00:00:00Z", *revisions[1].NewValue)
+	assert.Equal(t, "password_protected", revisions[2].Field)
+	for _, r := range revisions {
+		assert.Equal(t, uint(7), r.URLID)
+		assert.Equal(t, int64(2), r.Revision)
+		assert.Equal(t, uint(1), *r.UserID)
+	}
+}
+
+func revisionRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "url_id", "revision", "user_id", "field", "old_value", "new_value", "created_at"})
+}
+
+func TestURLService_GetURLHistory(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewURLService(db)
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	expectOwnedURL(mock, 3)
+	mock.ExpectQuery(`SELECT \* FROM "url_revisions" WHERE url_id = \$1 ORDER BY revision DESC, id`).
+		WithArgs(uint(7)).
+		WillReturnRows(revisionRows().
+			AddRow(3, 7, 3, 1, "title", "B", "C", at).
+			AddRow(1, 7, 2, 1, "original_url", "https://a.example.com", "https://b.example.com", at).
+			AddRow(2, 7, 2, 1, "title", "A", "B", at))
+
+	history, err := service.GetURLHistory(context.Background(), 1, 7)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, int64(3), history[0].Revision)
+	assert.Len(t, history[0].Changes, 1)
+	assert.Equal(t, int64(2), history[1].Revision)
+	assert.Len(t, history[1].Changes, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestURLService_RestoreRevision(t *testing.T) {
+	t.Run("undoes later changes", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		// The stored URL is titled "Old title" at version 3, after revision 2
+		// renamed it from "Draft" to "Launch" and revision 3 to "Old title"
+		expectOwnedURL(mock, 3)
+		mock.ExpectQuery(`SELECT \* FROM "url_revisions" WHERE url_id = \$1 AND revision > \$2 ORDER BY revision DESC, id DESC`).
+			WithArgs(uint(7), int64(1)).
+			WillReturnRows(revisionRows().
+				AddRow(3, 7, 3, 1, "title", "Launch", "Old title", time.Now()).
+				AddRow(2, 7, 2, 1, "title", "Draft", "Launch", time.Now()))
+		expectDestinationAllowed(mock)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "urls"`).
+			WithArgs("https://example.com", "abc123", "Draft", nil, nil, "", false, "", 0, int64(4), int64(3), uint(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO "url_revisions"`).
+			WithArgs(uint(7), int64(4), uint(1), "title", "Old title", "Draft", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectCommit()
+
+		url, err := service.RestoreRevision(context.Background(), 1, 7, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Draft", url.Title)
+		assert.Equal(t, int64(4), url.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("expiry date that has passed", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		// Revision 3 removed an expiry date that is in the past by now
+		expectOwnedURL(mock, 3)
+		mock.ExpectQuery(`SELECT \* FROM "url_revisions"`).
+			WithArgs(uint(7), int64(2)).
+			WillReturnRows(revisionRows().
+				AddRow(3, 7, 3, 1, "expires_at", "2026-10-02T12:00:00Z", nil, time.Now()))
+		expectDestinationAllowed(mock)
+		mock.ExpectBegin()
+		expiresAt := time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)
+		mock.ExpectExec(`UPDATE "urls"`).
+			WithArgs("https://example.com", "abc123", "Old title", expiresAt, nil, "", false, "", 0, int64(4), int64(3), uint(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO "url_revisions"`).
+			WithArgs(uint(7), int64(4), uint(1), "expires_at", nil, "2026-10-02T12:00:00Z", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectCommit()
+
+		url, err := service.RestoreRevision(context.Background(), 1, 7, 2)
+		require.NoError(t, err)
+		assert.Equal(t, expiresAt, *url.ExpiresAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revision out of range", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		expectOwnedURL(mock, 3)
+
+		_, err := service.RestoreRevision(context.Background(), 1, 7, 4)
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultRevocationCacheTTL bounds how long another instance's logout
+	// can go unnoticed
+	DefaultRevocationCacheTTL = 30 * time.Second
+	// DefaultRevocationPruneInterval is used when no prune interval is configured
+	DefaultRevocationPruneInterval = time.Minute
+)
+
+// AccessClaims are the claims of a validated access token
+type AccessClaims struct {
+	UserID uint
+	// ID is the jti claim; tokens issued before logout support have none
+	ID string
+	// IssuedAt has microseconds, or whole seconds for tokens issued before
+	// the iat_us claim
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type tokenRevocationEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// AccountStatus is what Auth needs to know about a user on every request
+type AccountStatus struct {
+	Role     string
+	Disabled bool
+	// Deleted is set for users that no longer exist
+	Deleted bool
+}
+
+type userRevocationEntry struct {
+	AccountStatus
+	// revokedAt rejects tokens issued up to then; zero if never revoked
+	revokedAt time.Time
+	expiresAt time.Time
+}
+
+// RevocationList tracks logged-out access tokens and the status of the
+// accounts they belong to. Lookups are cached in memory so most requests do
+// not reach the database: a revocation made through this instance applies at
+// once, one made through another instance once the cached lookup expires.
+// Cached entries and revoked tokens past their expiry are pruned in the
+// background, along with refresh tokens that expired or were revoked.
+type RevocationList struct {
+	db       *gorm.DB
+	ttl      time.Duration
+	interval time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]tokenRevocationEntry
+	users  map[uint]userRevocationEntry
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewRevocationList(db *gorm.DB, ttl, interval time.Duration) *RevocationList {
+	if ttl <= 0 {
+		ttl = DefaultRevocationCacheTTL
+	}
+	if interval <= 0 {
+		interval = DefaultRevocationPruneInterval
+	}
+	return &RevocationList{
+		db:       db,
+		ttl:      ttl,
+		interval: interval,
+		now:      time.Now,
+		tokens:   make(map[string]tokenRevocationEntry),
+		users:    make(map[uint]userRevocationEntry),
+		stop:     make(chan struct{}),
+	}
+}
+
+// IsRevoked reports whether the token with claims was logged out, either by
+// itself or by logging its user out everywhere
+func (l *RevocationList) IsRevoked(ctx context.Context, claims *AccessClaims) (bool, error) {
+	user, err := l.userEntry(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	// Both times have microseconds, so only a token issued in the very
+	// microsecond of the logout is refused even though it came after it.
+	// Tokens with whole-second issue times are refused for all of that second.
+	if user.Deleted || (!user.revokedAt.IsZero() && !claims.IssuedAt.After(user.revokedAt)) {
+		return true, nil
+	}
+	if claims.ID == "" {
+		return false, nil
+	}
+	return l.tokenRevoked(ctx, claims)
+}
+
+// RevokeToken logs out the token with claims until it expires
+func (l *RevocationList) RevokeToken(ctx context.Context, claims *AccessClaims) error {
+	err := l.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: claims.ExpiresAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.tokens[claims.ID] = tokenRevocationEntry{revoked: true, expiresAt: claims.ExpiresAt}
+	l.mu.Unlock()
+	return nil
+}
+
+// RevokeUser logs out every token issued to userID up to at
+func (l *RevocationList) RevokeUser(ctx context.Context, userID uint, at time.Time) error {
+	// Keep to what the database stores, so the cached time matches it
+	at = at.Truncate(time.Microsecond)
+	err := l.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("tokens_revoked_at", at).Error
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	if entry, ok := l.users[userID]; ok {
+		entry.revokedAt = at
+		l.users[userID] = entry
+	}
+	l.mu.Unlock()
+	return nil
+}
+
+// Account returns the role of userID and whether the account is disabled
+func (l *RevocationList) Account(ctx context.Context, userID uint) (*AccountStatus, error) {
+	user, err := l.userEntry(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &user.AccountStatus, nil
+}
+
+// Forget drops what is cached about userID, after their account changed
+func (l *RevocationList) Forget(userID uint) {
+	l.mu.Lock()
+	delete(l.users, userID)
+	l.mu.Unlock()
+}
+
+func (l *RevocationList) userEntry(ctx context.Context, userID uint) (userRevocationEntry, error) {
+	now := l.now()
+	l.mu.Lock()
+	entry, ok := l.users[userID]
+	l.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry, nil
+	}
+
+	var user models.User
+	err := l.db.WithContext(ctx).
+		Select("id", "tokens_revoked_at", "role", "disabled_at").
+		Where("id = ?", userID).
+		Take(&user).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		entry = userRevocationEntry{AccountStatus: AccountStatus{Deleted: true}}
+	case err != nil:
+		return userRevocationEntry{}, err
+	default:
+		entry = userRevocationEntry{AccountStatus: AccountStatus{
+			Role:     user.Role,
+			Disabled: user.DisabledAt != nil,
+		}}
+		if user.TokensRevokedAt != nil {
+			entry.revokedAt = *user.TokensRevokedAt
+		}
+	}
+	entry.expiresAt = now.Add(l.ttl)
+
+	l.mu.Lock()
+	l.users[userID] = entry
+	l.mu.Unlock()
+	return entry, nil
+}
+
+func (l *RevocationList) tokenRevoked(ctx context.Context, claims *AccessClaims) (bool, error) {
+	now := l.now()
+	l.mu.Lock()
+	entry, ok := l.tokens[claims.ID]
+	l.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	var count int64
+	if err := l.db.WithContext(ctx).Model(&models.RevokedToken{}).Where("jti = ?", claims.ID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	// A revoked token stays revoked, so it can be cached until it expires
+	entry = tokenRevocationEntry{revoked: count > 0, expiresAt: now.Add(l.ttl)}
+	if entry.revoked {
+		entry.expiresAt = claims.ExpiresAt
+	}
+
+	l.mu.Lock()
+	l.tokens[claims.ID] = entry
+	l.mu.Unlock()
+	return entry.revoked, nil
+}
+
+// Start launches the background prune loop
+func (l *RevocationList) Start() {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := l.Prune(l.now()); err != nil {
+					logger.Error("Failed to prune revoked and refresh tokens: %v", err)
+				}
+			case <-l.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the prune loop and waits for a running prune to finish
+func (l *RevocationList) Stop() {
+	close(l.stop)
+	l.wg.Wait()
+}
+
+// Prune drops cached lookups and revoked tokens that expired as of now, and
+// refresh tokens that expired or were revoked. Refresh rejects those before
+// looking any further, so deleting them changes no outcome. It returns how
+// many rows it deleted.
+func (l *RevocationList) Prune(now time.Time) (int64, error) {
+	l.mu.Lock()
+	for jti, entry := range l.tokens {
+		if !now.Before(entry.expiresAt) {
+			delete(l.tokens, jti)
+		}
+	}
+	for userID, entry := range l.users {
+		if !now.Before(entry.expiresAt) {
+			delete(l.users, userID)
+		}
+	}
+	l.mu.Unlock()
+
+	result := l.db.Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	pruned := result.RowsAffected
+
+	result = l.db.Where("expires_at <= ? OR revoked_at IS NOT NULL", now).Delete(&models.RefreshToken{})
+	return pruned + result.RowsAffected, result.Error
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func TestRevocationList_IsRevoked(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	claims := &AccessClaims{UserID: 1, ID: "abc", IssuedAt: now.Add(-time.Minute), ExpiresAt: now.Add(10 * time.Minute)}
+
+	newList := func(t *testing.T) (*RevocationList, sqlmock.Sqlmock) {
+		db, mock := setupTestDB(t)
+		list := NewRevocationList(db, 30*time.Second, time.Minute)
+		list.now = func() time.Time { return now }
+		return list, mock
+	}
+	expectUser := func(mock sqlmock.Sqlmock, revokedAt interface{}) {
+		mock.ExpectQuery(`SELECT "id","tokens_revoked_at","role","disabled_at" FROM "users" WHERE id = \$1 AND "users"\."deleted_at" IS NULL LIMIT \$2`).
+			WithArgs(uint(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "tokens_revoked_at", "role", "disabled_at"}).AddRow(1, revokedAt, "user", nil))
+	}
+
+	t.Run("lookups are cached", func(t *testing.T) {
+		list, mock := newList(t)
+		expectUser(mock, nil)
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "revoked_tokens" WHERE jti = \$1`).
+			WithArgs("abc").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		for i := 0; i < 2; i++ {
+			revoked, err := list.IsRevoked(context.Background(), claims)
+			require.NoError(t, err)
+			assert.False(t, revoked)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("logged out everywhere", func(t *testing.T) {
+		list, mock := newList(t)
+		expectUser(mock, now.Add(-30*time.Second))
+
+		revoked, err := list.IsRevoked(context.Background(), claims)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("login right after logging out everywhere", func(t *testing.T) {
+		list, mock := newList(t)
+		// ValidateToken checks expiry against the clock, so this runs now
+		revokedAt := time.Now().Truncate(time.Second).Add(400 * time.Millisecond)
+		expectUser(mock, revokedAt)
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "revoked_tokens"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		auth := NewAuthService(nil, "secret")
+		auth.now = func() time.Time { return revokedAt.Add(100 * time.Millisecond) }
+		token, err := auth.generateToken(&models.User{ID: 1})
+		require.NoError(t, err)
+		fresh, err := auth.ValidateToken(token)
+		require.NoError(t, err)
+
+		revoked, err := list.IsRevoked(context.Background(), fresh)
+		require.NoError(t, err)
+		assert.False(t, revoked, "token issued after the logout")
+
+		auth.now = func() time.Time { return revokedAt.Add(-100 * time.Millisecond) }
+		token, err = auth.generateToken(&models.User{ID: 1})
+		require.NoError(t, err)
+		sameSecond, err := auth.ValidateToken(token)
+		require.NoError(t, err)
+		revoked, err = list.IsRevoked(context.Background(), sameSecond)
+		require.NoError(t, err)
+		assert.True(t, revoked, "token issued earlier in the second of the logout")
+
+		legacy := &AccessClaims{UserID: 1, ID: "old", IssuedAt: revokedAt.Truncate(time.Second)}
+		revoked, err = list.IsRevoked(context.Background(), legacy)
+		require.NoError(t, err)
+		assert.True(t, revoked, "token with whole-second iat from the second of the logout")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoked here applies at once", func(t *testing.T) {
+		list, mock := newList(t)
+		expectUser(mock, nil)
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "revoked_tokens"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "revoked_tokens" \("jti","user_id","expires_at","created_at"\) VALUES \(\$1,\$2,\$3,\$4\) ON CONFLICT DO NOTHING`).
+			WithArgs("abc", uint(1), claims.ExpiresAt, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		revoked, err := list.IsRevoked(context.Background(), claims)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		require.NoError(t, list.RevokeToken(context.Background(), claims))
+		revoked, err = list.IsRevoked(context.Background(), claims)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRevocationList_Prune(t *testing.T) {
+	db, mock := setupTestDB(t)
+	list := NewRevocationList(db, 30*time.Second, time.Minute)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	list.tokens["old"] = tokenRevocationEntry{revoked: true, expiresAt: now}
+	list.tokens["new"] = tokenRevocationEntry{revoked: true, expiresAt: now.Add(time.Minute)}
+	list.users[1] = userRevocationEntry{expiresAt: now.Add(-time.Second)}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "revoked_tokens" WHERE expires_at <= \$1`).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "refresh_tokens" WHERE expires_at <= \$1 OR revoked_at IS NOT NULL`).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	pruned, err := list.Prune(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), pruned)
+	assert.Len(t, list.tokens, 1)
+	assert.Contains(t, list.tokens, "new")
+	assert.Empty(t, list.users)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// MinShortCodeLength is the shortest code a generator will produce
+	MinShortCodeLength = 4
+	// MaxShortCodeLength matches the varchar(10) short_code column
+	MaxShortCodeLength = 10
+	// DefaultShortCodeLength is used when no length is configured
+	DefaultShortCodeLength = 7
+
+	// maxShortCodeAttempts bounds the number of inserts tried on collisions
+	maxShortCodeAttempts = 5
+)
+
+// Supported short code styles
+const (
+	ShortCodeStyleRandom        = "random"
+	ShortCodeStyleHashids       = "hashids"
+	ShortCodeStylePronounceable = "pronounceable"
+)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// hashidsMultiplier is prime and coprime with 62^n for every n
+var hashidsMultiplier = big.NewInt(1580030173)
+
+var ErrInvalidShortCodeLength = fmt.Errorf("short code length must be between %d and %d", MinShortCodeLength, MaxShortCodeLength)
+
+// ShortCodeGenerator produces candidate short codes for URLs created without one
+type ShortCodeGenerator interface {
+	// Generate returns a candidate code. id is the row ID the URL will be
+	// stored under (only set when NeedsID reports true) and attempt is the
+	// zero-based retry counter, incremented after each collision.
+	Generate(id uint, attempt int) (string, error)
+	// NeedsID reports whether codes are derived from the row ID
+	NeedsID() bool
+}
+
+// NewShortCodeGenerator returns the generator for the given style
+func NewShortCodeGenerator(style string, length int, salt string) (ShortCodeGenerator, error) {
+	if length == 0 {
+		length = DefaultShortCodeLength
+	}
+	if length < MinShortCodeLength || length > MaxShortCodeLength {
+		return nil, ErrInvalidShortCodeLength
+	}
+
+	switch strings.ToLower(style) {
+	case "", ShortCodeStyleRandom:
+		return &RandomCodeGenerator{length: length}, nil
+	case ShortCodeStyleHashids:
+		return NewHashidsCodeGenerator(length, salt), nil
+	case ShortCodeStylePronounceable:
+		return &PronounceableCodeGenerator{length: length}, nil
+	default:
+		return nil, fmt.Errorf("unknown short code style %q", style)
+	}
+}
+
+// RandomCodeGenerator produces random base62 codes
+type RandomCodeGenerator struct {
+	length int
+}
+
+func (g *RandomCodeGenerator) Generate(id uint, attempt int) (string, error) {
+	return randomString(base62Alphabet, g.length)
+}
+
+func (g *RandomCodeGenerator) NeedsID() bool {
+	return false
+}
+
+// HashidsCodeGenerator encodes the row ID with a salted alphabet, so codes
+// are short, unique per row and not trivially sequential
+type HashidsCodeGenerator struct {
+	length   int
+	alphabet string
+}
+
+func NewHashidsCodeGenerator(length int, salt string) *HashidsCodeGenerator {
+	return &HashidsCodeGenerator{
+		length:   length,
+		alphabet: shuffleAlphabet(base62Alphabet, salt),
+	}
+}
+
+func (g *HashidsCodeGenerator) Generate(id uint, attempt int) (string, error) {
+	if id == 0 {
+		return "", errors.New("hashids short codes require a row id")
+	}
+
+	// A collision can only happen against a custom alias, so each retry
+	// reshuffles the alphabet to move to a different code for the same ID
+	alphabet := g.alphabet
+	for i := 0; i < attempt; i++ {
+		alphabet = shuffleAlphabet(alphabet, alphabet)
+	}
+
+	// Grow the code past the configured length once the ID space runs out
+	base := big.NewInt(int64(len(alphabet)))
+	length := g.length
+	space := new(big.Int).Exp(base, big.NewInt(int64(length)), nil)
+	n := new(big.Int).SetUint64(uint64(id))
+	for n.Cmp(space) >= 0 {
+		length++
+		space.Mul(space, base)
+	}
+	if length > MaxShortCodeLength {
+		return "", ErrInvalidShortCodeLength
+	}
+
+	// Multiplying by a constant coprime with the code space is a bijection,
+	// so consecutive IDs map to unrelated looking but still unique codes
+	n.Mul(n, hashidsMultiplier).Mod(n, space)
+
+	encoded := make([]byte, length)
+	digit := new(big.Int)
+	for i := length - 1; i >= 0; i-- {
+		n.DivMod(n, base, digit)
+		encoded[i] = alphabet[digit.Int64()]
+	}
+
+	return string(encoded), nil
+}
+
+func (g *HashidsCodeGenerator) NeedsID() bool {
+	return true
+}
+
+// PronounceableCodeGenerator produces word-like codes of alternating
+// consonants and vowels, e.g. "bakotu", which are easier to read aloud
+type PronounceableCodeGenerator struct {
+	length int
+}
+
+const (
+	consonants = "bdfghjklmnprstvz"
+	vowels     = "aeiou"
+)
+
+func (g *PronounceableCodeGenerator) Generate(id uint, attempt int) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < g.length; i++ {
+		set := consonants
+		if i%2 == 1 {
+			set = vowels
+		}
+		c, err := randomString(set, 1)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(c)
+	}
+	return sb.String(), nil
+}
+
+func (g *PronounceableCodeGenerator) NeedsID() bool {
+	return false
+}
+
+func randomString(alphabet string, length int) (string, error) {
+	max := big.NewInt(int64(len(alphabet)))
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// shuffleAlphabet is the consistent shuffle used by hashids
+func shuffleAlphabet(alphabet, salt string) string {
+	if salt == "" {
+		return alphabet
+	}
+
+	result := []byte(alphabet)
+	for i, v, p := len(result)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		p += int(salt[v])
+		j := (int(salt[v]) + v + p) % i
+		result[i], result[j] = result[j], result[i]
+		v++
+	}
+	return string(result)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShortCodeGenerator(t *testing.T) {
+	tests := []struct {
+		name    string
+		style   string
+		length  int
+		pattern string
+		wantErr bool
+	}{
+		{
+			name:    "random base62",
+			style:   ShortCodeStyleRandom,
+			length:  7,
+			pattern: `^[0-9A-Za-z]{7}$`,
+		},
+		{
+			name:    "default style and length",
+			style:   "",
+			length:  0,
+			pattern: `^[0-9A-Za-z]{7}$`,
+		},
+		{
+			name:    "pronounceable",
+			style:   ShortCodeStylePronounceable,
+			length:  6,
+			pattern: `^([bdfghjklmnprstvz][aeiou]){3}$`,
+		},
+		{
+			name:    "length above column size",
+			style:   ShortCodeStyleRandom,
+			length:  11,
+			wantErr: true,
+		},
+		{
+			name:    "length too short",
+			style:   ShortCodeStyleRandom,
+			length:  3,
+			wantErr: true,
+		},
+		{
+			name:    "unknown style",
+			style:   "emoji",
+			length:  7,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := NewShortCodeGenerator(tt.style, tt.length, "salt")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			code, err := generator.Generate(0, 0)
+			require.NoError(t, err)
+			assert.Regexp(t, regexp.MustCompile(tt.pattern), code)
+		})
+	}
+}
+
+func TestHashidsCodeGenerator(t *testing.T) {
+	generator := NewHashidsCodeGenerator(6, "salt")
+	assert.True(t, generator.NeedsID())
+
+	seen := make(map[string]uint)
+	for id := uint(1); id <= 1000; id++ {
+		code, err := generator.Generate(id, 0)
+		require.NoError(t, err)
+		assert.Len(t, code, 6)
+		if other, ok := seen[code]; ok {
+			t.Fatalf("ids %d and %d both encode to %q", other, id, code)
+		}
+		seen[code] = id
+	}
+
+	first, err := generator.Generate(42, 0)
+	require.NoError(t, err)
+	again, err := generator.Generate(42, 0)
+	require.NoError(t, err)
+	retry, err := generator.Generate(42, 1)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+	assert.NotEqual(t, first, retry)
+
+	_, err = generator.Generate(0, 0)
+	assert.Error(t, err)
+}
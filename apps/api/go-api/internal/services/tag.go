@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+const (
+	// MaxTagNameLength matches the varchar(50) name column
+	MaxTagNameLength = 50
+	// MaxURLTags bounds the number of tags on one link
+	MaxURLTags = 20
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("a tag with that name already exists")
+	ErrInvalidTag  = errors.New("invalid tag")
+)
+
+type TagServiceInterface interface {
+	ListTags(ctx context.Context, userID uint) ([]models.TagResponse, error)
+	CreateTag(ctx context.Context, userID uint, req *models.TagRequest) (*models.TagResponse, error)
+	UpdateTag(ctx context.Context, userID uint, id uint, req *models.TagRequest) (*models.TagResponse, error)
+	DeleteTag(ctx context.Context, userID uint, id uint) error
+	SetURLTags(ctx context.Context, userID uint, urlID uint, names []string) ([]string, error)
+}
+
+type TagService struct {
+	db *gorm.DB
+}
+
+func NewTagService(db *gorm.DB) *TagService {
+	return &TagService{db: db}
+}
+
+// ListTags returns userID's tags by name, each with the number of links in
+// use that carry it
+func (s *TagService) ListTags(ctx context.Context, userID uint) ([]models.TagResponse, error) {
+	tags := []models.TagResponse{}
+	err := s.tagsWithLinks(ctx).
+		Where("tags.owner = ?", userID).
+		Order("tags.name").
+		Scan(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (s *TagService) CreateTag(ctx context.Context, userID uint, req *models.TagRequest) (*models.TagResponse, error) {
+	name, err := normalizeTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{Owner: userID, Name: name}
+	if err := s.db.WithContext(ctx).Create(tag).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			return nil, ErrTagExists
+		}
+		return nil, err
+	}
+	return &models.TagResponse{ID: tag.ID, Name: tag.Name, CreatedAt: tag.CreatedAt}, nil
+}
+
+// UpdateTag renames a tag
+func (s *TagService) UpdateTag(ctx context.Context, userID uint, id uint, req *models.TagRequest) (*models.TagResponse, error) {
+	name, err := normalizeTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.Tag{}).
+		Where("id = ? AND owner = ?", id, userID).
+		Update("name", name)
+	if result.Error != nil {
+		if isDuplicateKeyError(result.Error) {
+			return nil, ErrTagExists
+		}
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrTagNotFound
+	}
+
+	var tag models.TagResponse
+	if err := s.tagsWithLinks(ctx).Where("tags.id = ?", id).Scan(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// DeleteTag removes a tag from every link carrying it, then deletes it
+func (s *TagService) DeleteTag(ctx context.Context, userID uint, id uint) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND owner = ?", id, userID).Delete(&models.Tag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// SetURLTags replaces the tags of a link with names, creating tags userID
+// does not have yet. It returns the link's tags as stored.
+func (s *TagService) SetURLTags(ctx context.Context, userID uint, urlID uint, names []string) ([]string, error) {
+	names, err := normalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var owned int64
+		if err := tx.Model(&models.URL{}).Where("id = ? AND owner = ?", urlID, userID).Count(&owned).Error; err != nil {
+			return err
+		}
+		if owned == 0 {
+			return ErrURLNotFound
+		}
+
+		if err := tx.Where("url_id = ?", urlID).Delete(&models.URLTag{}).Error; err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		tags := make([]models.Tag, len(names))
+		for i, name := range names {
+			tags[i] = models.Tag{Owner: userID, Name: name}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+		var ids []uint
+		if err := tx.Model(&models.Tag{}).Where("owner = ? AND name IN ?", userID, names).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+
+		links := make([]models.URLTag, len(ids))
+		for i, id := range ids {
+			links[i] = models.URLTag{URLID: urlID, TagID: id}
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// tagsWithLinks selects tags as TagResponses, counting the links in use
+// that carry each
+func (s *TagService) tagsWithLinks(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&models.Tag{}).
+		Select("tags.id, tags.name, tags.created_at, COUNT(urls.id) AS links").
+		Joins("LEFT JOIN url_tags ON url_tags.tag_id = tags.id").
+		Joins("LEFT JOIN urls ON urls.id = url_tags.url_id AND urls.deleted_at IS NULL").
+		Group("tags.id")
+}
+
+// normalizeTagName trims and lowercases a tag name, so tags match
+// regardless of case
+func normalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || len(name) > MaxTagNameLength {
+		return "", fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidTag, MaxTagNameLength)
+	}
+	return name, nil
+}
+
+// normalizeTagNames normalizes names, dropping duplicates, and sorts them
+func normalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	if len(normalized) > MaxURLTags {
+		return nil, fmt.Errorf("%w: a link can have at most %d tags", ErrInvalidTag, MaxURLTags)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// attachTags fills in the tags of each link in urls with one query
+func (s *URLService) attachTags(ctx context.Context, urls []models.URLResponse) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+	}
+
+	var rows []struct {
+		URLID uint
+		Name  string
+	}
+	err := s.db.WithContext(ctx).Table("url_tags").
+		Select("url_tags.url_id, tags.name").
+		Joins("JOIN tags ON tags.id = url_tags.tag_id").
+		Where("url_tags.url_id IN ?", ids).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[uint][]string)
+	for _, row := range rows {
+		tags[row.URLID] = append(tags[row.URLID], row.Name)
+	}
+	for i := range urls {
+		urls[i].Tags = tags[urls[i].ID]
+	}
+	return nil
+}
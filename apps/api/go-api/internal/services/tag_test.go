@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// expectURLTags expects the query loading link tags, returning rows of
+// url_id and tag name pairs
+func expectURLTags(mock sqlmock.Sqlmock, rows ...interface{}) {
+	result := sqlmock.NewRows([]string{"url_id", "name"})
+	for i := 0; i+1 < len(rows); i += 2 {
+		result.AddRow(rows[i], rows[i+1])
+	}
+	mock.ExpectQuery(`SELECT url_tags\.url_id, tags\.name FROM "url_tags" JOIN tags ON tags\.id = url_tags\.tag_id WHERE url_tags\.url_id IN`).
+		WillReturnRows(result)
+}
+
+func TestNormalizeTagNames(t *testing.T) {
+	names, err := normalizeTagNames([]string{" Spring ", "launch", "spring", "LAUNCH"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"launch", "spring"}, names)
+
+	_, err = normalizeTagNames([]string{"ok", " "})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	tooMany := make([]string, MaxURLTags+1)
+	for i := range tooMany {
+		tooMany[i] = string(rune('a' + i))
+	}
+	_, err = normalizeTagNames(tooMany)
+	assert.ErrorIs(t, err, ErrInvalidTag)
+}
+
+func TestTagService_CreateTag(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewTagService(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tags" \("owner","name","created_at"\)`).
+			WithArgs(uint(1), "spring-sale", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectCommit()
+
+		tag, err := service.CreateTag(context.Background(), 1, &models.TagRequest{Name: "Spring-Sale"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), tag.ID)
+		assert.Equal(t, "spring-sale", tag.Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewTagService(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tags"`).WillReturnError(&pgconn.PgError{Code: "23505"})
+		mock.ExpectRollback()
+
+		_, err := service.CreateTag(context.Background(), 1, &models.TagRequest{Name: "spring"})
+		assert.ErrorIs(t, err, ErrTagExists)
+	})
+}
+
+func TestTagService_SetURLTags(t *testing.T) {
+	t.Run("replaces tags", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewTagService(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "urls" WHERE \(id = \$1 AND owner = \$2\) AND "urls"\."deleted_at" IS NULL`).
+			WithArgs(uint(7), uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec(`DELETE FROM "url_tags" WHERE url_id = \$1`).
+			WithArgs(uint(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO "tags" \("owner","name","created_at"\) VALUES \(\$1,\$2,\$3\),\(\$4,\$5,\$6\) ON CONFLICT DO NOTHING`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectQuery(`SELECT "id" FROM "tags" WHERE owner = \$1 AND name IN \(\$2,\$3\)`).
+			WithArgs(uint(1), "launch", "spring").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(9))
+		mock.ExpectExec(`INSERT INTO "url_tags" \("url_id","tag_id"\) VALUES \(\$1,\$2\),\(\$3,\$4\)`).
+			WithArgs(uint(7), uint(3), uint(7), uint(9)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		tags, err := service.SetURLTags(context.Background(), 1, 7, []string{"Spring", "launch"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"launch", "spring"}, tags)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("link not owned", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewTagService(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "urls"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		_, err := service.SetURLTags(context.Background(), 1, 7, []string{"spring"})
+		assert.ErrorIs(t, err, ErrURLNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestURLService_GetUserURLs_ByTagAndFolder(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewURLService(db)
+	folder := uint(0)
+
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE owner = \$1 AND \(id IN \(SELECT url_tags\.url_id FROM url_tags JOIN tags ON tags\.id = url_tags\.tag_id WHERE tags\.owner = \$2 AND tags\.name = \$3\)\) AND folder_id IS NULL AND "urls"\."deleted_at" IS NULL`).
+		WithArgs(uint(1), uint(1), "spring", DefaultURLPageSize+1).
+		WillReturnRows(urlRows().AddRow(5, "https://example.com", "spr1ng", "Spring", 1, 0, nil, nil))
+	expectURLTags(mock, 5, "launch", 5, "spring")
+
+	urls, _, err := service.GetUserURLs(context.Background(), 1, &models.URLListQuery{Tag: " Spring", FolderID: &folder})
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	assert.Equal(t, []string{"launch", "spring"}, urls[0].Tags)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
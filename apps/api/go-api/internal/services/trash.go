@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+	"github.com/refsigregory/refurl/apps/api/go-api/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTrashRetention is how long deleted links stay restorable
+	DefaultTrashRetention = 30 * 24 * time.Hour
+	// DefaultTrashPurgeInterval is used when no purge interval is configured
+	DefaultTrashPurgeInterval = time.Hour
+)
+
+// WithTrashRetention sets how long deleted links stay in the trash, which is
+// reported as their purge time
+func WithTrashRetention(retention time.Duration) URLServiceOption {
+	return func(s *URLService) {
+		if retention > 0 {
+			s.retention = retention
+		}
+	}
+}
+
+// ListTrash returns the deleted links userID owns, most recently deleted first
+func (s *URLService) ListTrash(ctx context.Context, userID uint) ([]models.URLResponse, error) {
+	var urls []models.URL
+	err := s.db.WithContext(ctx).Unscoped().
+		Where("owner = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC, id DESC").
+		Find(&urls).Error
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.URLResponse, len(urls))
+	for i, url := range urls {
+		responses[i] = *toURLResponse(&url)
+		purgeAt := url.DeletedAt.Time.Add(s.retention)
+		responses[i].PurgeAt = &purgeAt
+	}
+	return responses, nil
+}
+
+// RestoreURL takes a URL out of the trash. Unverified users at their link
+// limit cannot restore links, as they could not create them.
+func (s *URLService) RestoreURL(ctx context.Context, userID uint, id uint) (*models.URLResponse, error) {
+	restore := func(db *gorm.DB) error {
+		result := db.Unscoped().Model(&models.URL{}).
+			Where("id = ? AND owner = ? AND deleted_at IS NOT NULL", id, userID).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrURLNotFound
+		}
+		return nil
+	}
+
+	var err error
+	if s.unverifiedLimit == 0 {
+		err = restore(s.db.WithContext(ctx))
+	} else {
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Only links outside the trash count, as restoring adds one to them
+			if err := s.checkLinkQuota(tx, userID, false); err != nil {
+				return err
+			}
+			return restore(tx)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetURLByID(ctx, userID, id)
+}
+
+// TrashPurger periodically hard-deletes links that have been in the trash
+// longer than the retention period, releasing their short codes
+type TrashPurger struct {
+	db        *gorm.DB
+	retention time.Duration
+	interval  time.Duration
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewTrashPurger(db *gorm.DB, retention, interval time.Duration) *TrashPurger {
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+	if interval <= 0 {
+		interval = DefaultTrashPurgeInterval
+	}
+	return &TrashPurger{
+		db:        db,
+		retention: retention,
+		interval:  interval,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start launches the background purge loop
+func (p *TrashPurger) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := p.Purge(time.Now()); err != nil {
+					logger.Error("Failed to purge deleted URLs: %v", err)
+				}
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the purge loop and waits for a running purge to finish
+func (p *TrashPurger) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+// Purge hard-deletes links deleted more than the retention period before now
+// and returns how many it removed. Their click events go with them.
+func (p *TrashPurger) Purge(now time.Time) (int64, error) {
+	result := p.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", now.Add(-p.retention)).
+		Delete(&models.URL{})
+	return result.RowsAffected, result.Error
+}
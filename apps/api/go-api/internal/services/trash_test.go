@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLService_ListTrash(t *testing.T) {
+	db, mock := setupTestDB(t)
+	service := NewURLService(db, WithTrashRetention(7*24*time.Hour))
+	deleted := time.Date(2026, 10, 10, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT \* FROM "urls" WHERE owner = \$1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC`).
+		WithArgs(uint(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_code", "owner", "deleted_at"}).AddRow(3, "gone", 1, deleted))
+
+	urls, err := service.ListTrash(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	assert.Equal(t, deleted, *urls[0].DeletedAt)
+	assert.Equal(t, deleted.Add(7*24*time.Hour), *urls[0].PurgeAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestURLService_RestoreURL(t *testing.T) {
+	t.Run("restored", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "urls" SET "deleted_at"=\$1 WHERE id = \$2 AND owner = \$3 AND deleted_at IS NOT NULL`).
+			WithArgs(nil, uint(3), uint(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM "urls" WHERE \(id = \$1 AND owner = \$2\) AND "urls"\."deleted_at" IS NULL`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "short_code", "owner"}).AddRow(3, "back", 1))
+		expectURLTags(mock)
+
+		url, err := service.RestoreURL(context.Background(), 1, 3)
+		require.NoError(t, err)
+		assert.Equal(t, "back", url.ShortCode)
+		assert.Nil(t, url.DeletedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unverified user at the limit", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db, WithUnverifiedLinkLimit(2))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT "id","email_verified_at" FROM "users" WHERE id = \$1 AND "users"\."deleted_at" IS NULL LIMIT \$2 FOR UPDATE`).
+			WithArgs(uint(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email_verified_at"}).AddRow(1, nil))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "urls" WHERE owner = \$1 AND "urls"\."deleted_at" IS NULL`).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectRollback()
+
+		_, err := service.RestoreURL(context.Background(), 1, 3)
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not in trash", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "urls" SET "deleted_at"`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		_, err := service.RestoreURL(context.Background(), 1, 3)
+		assert.ErrorIs(t, err, ErrURLNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTrashPurger_Purge(t *testing.T) {
+	db, mock := setupTestDB(t)
+	purger := NewTrashPurger(db, 24*time.Hour, time.Hour)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "urls" WHERE deleted_at IS NOT NULL AND deleted_at < \$1`).
+		WithArgs(now.Add(-24 * time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	purged, err := purger.Purge(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,152 +3,501 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrURLNotFound        = errors.New("url not found")
+	ErrShortCodeExhausted = errors.New("could not generate a unique short code")
+	ErrVersionMismatch    = errors.New("url has been modified since it was read")
 )
 
 type URLServiceInterface interface {
 	CreateURL(ctx context.Context, userID uint, req *models.CreateURLRequest) (*models.URLResponse, error)
 	GetURLByID(ctx context.Context, userID uint, id uint) (*models.URLResponse, error)
-	GetUserURLs(ctx context.Context, userID uint) ([]models.URLResponse, error)
-	UpdateURL(ctx context.Context, userID uint, id uint, req *models.UpdateURLRequest) (*models.URLResponse, error)
+	GetUserURLs(ctx context.Context, userID uint, query *models.URLListQuery) ([]models.URLResponse, *models.Pagination, error)
+	UpdateURL(ctx context.Context, userID uint, id uint, req *models.UpdateURLRequest, version int64) (*models.URLResponse, error)
+	PatchURL(ctx context.Context, userID uint, id uint, patch []byte, version int64) (*models.URLResponse, error)
 	DeleteURL(ctx context.Context, userID uint, id uint) error
+	ListTrash(ctx context.Context, userID uint) ([]models.URLResponse, error)
+	RestoreURL(ctx context.Context, userID uint, id uint) (*models.URLResponse, error)
+	GetURLHistory(ctx context.Context, userID uint, id uint) ([]models.URLRevisionResponse, error)
+	RestoreRevision(ctx context.Context, userID uint, id uint, revision int64) (*models.URLResponse, error)
+	BulkCreateURLs(ctx context.Context, userID uint, reqs []models.CreateURLRequest) ([]models.BulkResult, error)
+	BulkUpdateURLs(ctx context.Context, userID uint, items []models.BulkUpdateItem) ([]models.BulkResult, error)
+	BulkDeleteURLs(ctx context.Context, userID uint, ids []uint) ([]models.BulkResult, error)
+	ImportURLs(ctx context.Context, userID uint, records []models.ImportRecord, dryRun bool) (*models.ImportReport, error)
+	ExportURLs(ctx context.Context, userID uint, includeClicks bool, w URLExportWriter) error
 	GetURLByShortCode(ctx context.Context, shortCode string) (*models.URLResponse, error)
+	RecordClick(ctx context.Context, click *models.ClickEvent) error
+	ClaimClick(ctx context.Context, click *models.ClickEvent) error
+	UnlockURL(ctx context.Context, shortCode, password, clientKey string) (*models.URLResponse, string, error)
+	VerifyUnlockToken(url *models.URLResponse, token string) bool
+	UnlockTTL() time.Duration
+	RedirectStatus(url *models.URLResponse) int
 }
 
 type URLService struct {
-	db *gorm.DB
+	db           *gorm.DB
+	generator    ShortCodeGenerator
+	aliases      *AliasPolicy
+	clicks       *ClickRecorder
+	cache        *RedirectCache
+	lock         *LinkLock
+	destinations *DestinationPolicy
+
+	redirectStatus int
+	retention      time.Duration
+	// unverifiedLimit caps the links of users with unverified email; 0 means
+	// no cap
+	unverifiedLimit int
+}
+
+// URLServiceOption configures optional URLService dependencies
+type URLServiceOption func(*URLService)
+
+// WithShortCodeGenerator sets the generator used when a URL is created
+// without a short code
+func WithShortCodeGenerator(generator ShortCodeGenerator) URLServiceOption {
+	return func(s *URLService) {
+		s.generator = generator
+	}
+}
+
+// WithAliasPolicy sets the policy custom short codes are validated against
+func WithAliasPolicy(policy *AliasPolicy) URLServiceOption {
+	return func(s *URLService) {
+		s.aliases = policy
+	}
+}
+
+// WithClickRecorder makes RecordClick queue clicks on recorder instead of
+// writing them synchronously
+func WithClickRecorder(recorder *ClickRecorder) URLServiceOption {
+	return func(s *URLService) {
+		s.clicks = recorder
+	}
+}
+
+// WithRedirectCache serves short code lookups from cache where possible
+func WithRedirectCache(cache *RedirectCache) URLServiceOption {
+	return func(s *URLService) {
+		s.cache = cache
+	}
+}
+
+// WithLinkLock sets how password-protected links are unlocked
+func WithLinkLock(lock *LinkLock) URLServiceOption {
+	return func(s *URLService) {
+		s.lock = lock
+	}
 }
 
-func NewURLService(db *gorm.DB) *URLService {
-	return &URLService{db: db}
+// WithDestinationPolicy sets the policy destination and fallback URLs are
+// checked against
+func WithDestinationPolicy(policy *DestinationPolicy) URLServiceOption {
+	return func(s *URLService) {
+		s.destinations = policy
+	}
+}
+
+// WithDefaultRedirectStatus sets the redirect status of links that do not
+// choose their own. Unsupported values are ignored.
+func WithDefaultRedirectStatus(status int) URLServiceOption {
+	return func(s *URLService) {
+		if ValidRedirectStatus(status) {
+			s.redirectStatus = status
+		}
+	}
+}
+
+// WithUnverifiedLinkLimit caps how many links users who have not verified
+// their email can own
+func WithUnverifiedLinkLimit(limit int) URLServiceOption {
+	return func(s *URLService) {
+		if limit > 0 {
+			s.unverifiedLimit = limit
+		}
+	}
+}
+
+func NewURLService(db *gorm.DB, opts ...URLServiceOption) *URLService {
+	s := &URLService{
+		db:             db,
+		generator:      &RandomCodeGenerator{length: DefaultShortCodeLength},
+		aliases:        NewAliasPolicy(MinShortCodeLength, MaxShortCodeLength, DefaultReservedAliases),
+		redirectStatus: DefaultRedirectStatus,
+		retention:      DefaultTrashRetention,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.lock == nil {
+		s.lock = NewLinkLock("", DefaultUnlockTTL, nil, nil)
+	}
+	if s.destinations == nil {
+		s.destinations = NewDestinationPolicy(db, nil, nil)
+	}
+	return s
 }
 
 func (s *URLService) CreateURL(ctx context.Context, userID uint, req *models.CreateURLRequest) (*models.URLResponse, error) {
+	if err := s.validateCreate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var url *models.URLResponse
+	err := s.withLinkQuota(ctx, userID, func(db *gorm.DB) error {
+		var err error
+		url, err = s.insertURL(db, userID, req, time.Time{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
+// withLinkQuota runs insert, which adds a link for userID, once checkLinkQuota
+// allows it. The check and insert share a transaction holding the user row
+// locked, so concurrent creates cannot all pass the check.
+func (s *URLService) withLinkQuota(ctx context.Context, userID uint, insert func(db *gorm.DB) error) error {
+	if s.unverifiedLimit == 0 {
+		return insert(s.db)
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.checkLinkQuota(tx, userID, true); err != nil {
+			return err
+		}
+		return insert(tx)
+	})
+}
+
+// checkLinkQuota rejects another link for userID while their email is
+// unverified and they already own the unverified limit. Links in the trash
+// count when withTrash is set, so deleting links does not make room for new
+// ones. tx must be a transaction: the user row stays locked until it ends.
+func (s *URLService) checkLinkQuota(tx *gorm.DB, userID uint, withTrash bool) error {
+	if s.unverifiedLimit == 0 {
+		return nil
+	}
+
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "email_verified_at").
+		Where("id = ?", userID).
+		Take(&user).Error
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	query := tx.Model(&models.URL{}).Where("owner = ?", userID)
+	if withTrash {
+		query = query.Unscoped()
+	}
+	var owned int64
+	if err := query.Count(&owned).Error; err != nil {
+		return err
+	}
+	if owned >= int64(s.unverifiedLimit) {
+		return fmt.Errorf("%w: verify your email to create more than %d links", ErrEmailNotVerified, s.unverifiedLimit)
+	}
+	return nil
+}
+
+// validateCreate applies the checks a link must pass before it is inserted
+func (s *URLService) validateCreate(ctx context.Context, req *models.CreateURLRequest) error {
+	if err := validateExpiry(req.ExpiresAt, req.MaxClicks, time.Now()); err != nil {
+		return err
+	}
+	if err := validateRedirectStatus(req.RedirectStatus); err != nil {
+		return err
+	}
+	if req.ShortCode != "" {
+		if err := s.aliases.Validate(req.ShortCode); err != nil {
+			return err
+		}
+	}
+	return s.checkDestinations(ctx, req.OriginalURL, req.FallbackURL, req.ShortCode)
+}
+
+// insertURL stores a validated link through db. A zero createdAt means now.
+func (s *URLService) insertURL(db *gorm.DB, userID uint, req *models.CreateURLRequest, createdAt time.Time) (*models.URLResponse, error) {
 	url := &models.URL{
 		OriginalURL: req.OriginalURL,
 		Title:       req.Title,
 		ShortCode:   req.ShortCode,
 		Owner:       userID,
 		Clicks:      0,
+		CreatedAt:   createdAt,
 		ClicksAt:    time.Now(),
+		ExpiresAt:   req.ExpiresAt,
+		MaxClicks:   req.MaxClicks,
+		FallbackURL: req.FallbackURL,
+
+		RedirectStatus: req.RedirectStatus,
+		Version:        1,
+	}
+	if req.Password != "" {
+		hashed, err := hashLinkPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		url.Password = hashed
 	}
 
-	if err := s.db.Create(url).Error; err != nil {
-		return nil, err
+	if url.ShortCode == "" {
+		if err := s.createWithGeneratedCode(db, url); err != nil {
+			return nil, err
+		}
+	} else if err := db.Create(url).Error; err != nil {
+		return nil, s.shortCodeError(err, url.ShortCode)
 	}
 
-	return &models.URLResponse{
-		ID:          url.ID,
-		OriginalURL: url.OriginalURL,
-		ShortCode:   url.ShortCode,
-		Title:       url.Title,
-		Clicks:      url.Clicks,
-		CreatedAt:   url.CreatedAt,
-		ClicksAt:    url.ClicksAt,
-	}, nil
+	return toURLResponse(url), nil
+}
+
+// checkDestinations applies the destination policy to a link's original URL
+// and, when it has one, its fallback URL
+func (s *URLService) checkDestinations(ctx context.Context, originalURL, fallbackURL, shortCode string) error {
+	if err := s.destinations.Check(ctx, originalURL, shortCode); err != nil {
+		return err
+	}
+	if fallbackURL != "" {
+		if err := s.destinations.Check(ctx, fallbackURL, shortCode); err != nil {
+			return fmt.Errorf("fallback url: %w", err)
+		}
+	}
+	return nil
+}
+
+// createWithGeneratedCode inserts url under a generated short code, retrying
+// with a fresh code whenever the unique short code index rejects it. When db
+// is a transaction each attempt runs in a savepoint, so a rejected code does
+// not abort it.
+func (s *URLService) createWithGeneratedCode(db *gorm.DB, url *models.URL) error {
+	for attempt := 0; attempt < maxShortCodeAttempts; attempt++ {
+		if s.generator.NeedsID() && url.ID == 0 {
+			id, err := s.nextURLID()
+			if err != nil {
+				return err
+			}
+			url.ID = id
+		}
+
+		code, err := s.generator.Generate(url.ID, attempt)
+		if err != nil {
+			return err
+		}
+		if s.aliases.IsReserved(code) {
+			continue
+		}
+		url.ShortCode = code
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return tx.Create(url).Error
+		})
+		if err == nil {
+			return nil
+		}
+		if !isDuplicateKeyError(err) {
+			return err
+		}
+	}
+
+	return ErrShortCodeExhausted
+}
+
+// shortCodeError turns a unique violation on code into a
+// ShortCodeConflictError carrying free alternatives
+func (s *URLService) shortCodeError(err error, code string) error {
+	if !isDuplicateKeyError(err) {
+		return err
+	}
+
+	suggestions, suggestErr := s.aliases.Suggest(s.db, code)
+	if suggestErr != nil {
+		return suggestErr
+	}
+	return &ShortCodeConflictError{ShortCode: code, Suggestions: suggestions}
+}
+
+// nextURLID reserves the next value of the urls identity sequence
+func (s *URLService) nextURLID() (uint, error) {
+	var id uint
+	if err := s.db.Raw("SELECT nextval(pg_get_serial_sequence('urls', 'id'))").Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return id, nil
 }
 
 func (s *URLService) GetURLByID(ctx context.Context, userID uint, id uint) (*models.URLResponse, error) {
 	var url models.URL
 	if err := s.db.Where("id = ? AND owner = ?", id, userID).First(&url).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("url not found")
+			return nil, ErrURLNotFound
 		}
 		return nil, err
 	}
 
-	return &models.URLResponse{
-		ID:          url.ID,
-		OriginalURL: url.OriginalURL,
-		ShortCode:   url.ShortCode,
-		Title:       url.Title,
-		Clicks:      url.Clicks,
-		CreatedAt:   url.CreatedAt,
-		ClicksAt:    url.ClicksAt,
-	}, nil
+	resp := []models.URLResponse{*toURLResponse(&url)}
+	if err := s.attachTags(ctx, resp); err != nil {
+		return nil, err
+	}
+	return &resp[0], nil
 }
 
-func (s *URLService) GetUserURLs(ctx context.Context, userID uint) ([]models.URLResponse, error) {
-	var urls []models.URL
-	if err := s.db.Where("owner = ?", userID).Find(&urls).Error; err != nil {
+// UpdateURL replaces the editable fields of a URL. A non-zero version must
+// match the stored one or ErrVersionMismatch is returned.
+func (s *URLService) UpdateURL(ctx context.Context, userID uint, id uint, req *models.UpdateURLRequest, version int64) (*models.URLResponse, error) {
+	url, err := s.ownedURL(ctx, userID, id)
+	if err != nil {
 		return nil, err
 	}
+	return s.applyUpdate(ctx, userID, url, req, version, false)
+}
 
-	responses := make([]models.URLResponse, len(urls))
-	for i, url := range urls {
-		responses[i] = models.URLResponse{
-			ID:          url.ID,
-			OriginalURL: url.OriginalURL,
-			ShortCode:   url.ShortCode,
-			Title:       url.Title,
-			Clicks:      url.Clicks,
-			CreatedAt:   url.CreatedAt,
-			ClicksAt:    url.ClicksAt,
+// ownedURL loads the URL with the given ID if userID owns it
+func (s *URLService) ownedURL(ctx context.Context, userID uint, id uint) (*models.URL, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).Where("id = ? AND owner = ?", id, userID).First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrURLNotFound
 		}
+		return nil, err
 	}
-
-	return responses, nil
+	return &url, nil
 }
 
-func (s *URLService) UpdateURL(ctx context.Context, userID uint, id uint, req *models.UpdateURLRequest) (*models.URLResponse, error) {
-	var url models.URL
-	if err := s.db.Where("id = ? AND owner = ?", id, userID).First(&url).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("url not found")
+// applyUpdate writes req over url on behalf of userID and records the changed
+// fields as a new revision. A restore skips the expiry checks: the restored
+// values were accepted once, and a date that has passed since only leaves
+// the link expired.
+func (s *URLService) applyUpdate(ctx context.Context, userID uint, url *models.URL, req *models.UpdateURLRequest, version int64, restore bool) (*models.URLResponse, error) {
+	if version != 0 && version != url.Version {
+		return nil, ErrVersionMismatch
+	}
+
+	if req.ShortCode != url.ShortCode {
+		if err := s.aliases.Validate(req.ShortCode); err != nil {
+			return nil, err
 		}
+	}
+	limitsChanged, err := validateExpiryUpdate(url, req.ExpiresAt, req.MaxClicks, time.Now())
+	if err != nil && !restore {
+		return nil, err
+	}
+	if err := validateRedirectStatus(req.RedirectStatus); err != nil {
+		return nil, err
+	}
+	if err := s.checkDestinations(ctx, req.OriginalURL, req.FallbackURL, req.ShortCode); err != nil {
 		return nil, err
 	}
 
+	before := revisionValues(url)
 	url.OriginalURL = req.OriginalURL
 	url.Title = req.Title
 	url.ShortCode = req.ShortCode
+	url.ExpiresAt = req.ExpiresAt
+	url.MaxClicks = req.MaxClicks
+	url.FallbackURL = req.FallbackURL
+	url.RedirectStatus = req.RedirectStatus
+	// The sweeper marks the link again if the new limits are already reached
+	if limitsChanged {
+		url.Expired = false
+	}
+	if req.Password != nil {
+		url.Password = ""
+		if *req.Password != "" {
+			hashed, err := hashLinkPassword(*req.Password)
+			if err != nil {
+				return nil, err
+			}
+			url.Password = hashed
+		}
+	}
 
-	if err := s.db.Save(&url).Error; err != nil {
-		return nil, err
+	// The version check in the WHERE clause catches edits that landed after
+	// url was read
+	read := url.Version
+	url.Version++
+	revisions := diffRevision(url, userID, before, revisionValues(url))
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(url).
+			Where("version = ?", read).
+			Select("original_url", "title", "short_code", "expires_at", "max_clicks", "fallback_url",
+				"redirect_status", "expired", "password_hash", "version").
+			Updates(url)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionMismatch
+		}
+		if len(revisions) > 0 {
+			return tx.Create(&revisions).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, s.shortCodeError(err, url.ShortCode)
 	}
+	s.invalidate(url.ID)
 
-	return &models.URLResponse{
-		ID:          url.ID,
-		OriginalURL: url.OriginalURL,
-		ShortCode:   url.ShortCode,
-		Title:       url.Title,
-		Clicks:      url.Clicks,
-		CreatedAt:   url.CreatedAt,
-		ClicksAt:    url.ClicksAt,
-	}, nil
+	return toURLResponse(url), nil
 }
 
+// DeleteURL moves a URL to the trash, where it stays until purged
 func (s *URLService) DeleteURL(ctx context.Context, userID uint, id uint) error {
-	result := s.db.Unscoped().Where("id = ? AND owner = ?", id, userID).Delete(&models.URL{})
+	result := s.db.WithContext(ctx).Where("id = ? AND owner = ?", id, userID).Delete(&models.URL{})
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		return errors.New("url not found")
+		return ErrURLNotFound
 	}
+	s.invalidate(id)
 	return nil
 }
 
 func (s *URLService) GetURLByShortCode(ctx context.Context, shortCode string) (*models.URLResponse, error) {
+	var generation uint64
+	if s.cache != nil {
+		if cached, ok := s.cache.Get(shortCode); ok {
+			return cached, nil
+		}
+		// An update landing between the read and the Set must not leave
+		// the old row cached
+		generation = s.cache.Generation()
+	}
+
 	var url models.URL
 	if err := s.db.Where("short_code = ?", shortCode).First(&url).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("url not found")
+			return nil, ErrURLNotFound
 		}
 		return nil, err
 	}
 
-	// Increment clicks and update clicks_at
-	url.Clicks++
-	url.ClicksAt = time.Now()
-	if err := s.db.Save(&url).Error; err != nil {
-		return nil, err
+	// Links with a click limit need a fresh count on every visit
+	resp := toURLResponse(&url)
+	if s.cache != nil && url.MaxClicks == nil {
+		s.cache.SetIfCurrent(resp, generation)
 	}
+	return resp, nil
+}
 
-	return &models.URLResponse{
+func toURLResponse(url *models.URL) *models.URLResponse {
+	resp := &models.URLResponse{
 		ID:          url.ID,
 		OriginalURL: url.OriginalURL,
 		ShortCode:   url.ShortCode,
@@ -156,5 +505,37 @@ func (s *URLService) GetURLByShortCode(ctx context.Context, shortCode string) (*
 		Clicks:      url.Clicks,
 		CreatedAt:   url.CreatedAt,
 		ClicksAt:    url.ClicksAt,
-	}, nil
+		ExpiresAt:   url.ExpiresAt,
+		MaxClicks:   url.MaxClicks,
+		FallbackURL: url.FallbackURL,
+		Expired:     url.Expired,
+
+		PasswordProtected:   url.Password != "",
+		PasswordFingerprint: passwordFingerprint(url.Password),
+		RedirectStatus:      url.RedirectStatus,
+		Version:             url.Version,
+		FolderID:            url.FolderID,
+		Disabled:            url.DisabledAt != nil,
+		DisabledReason:      url.DisabledReason,
+	}
+	if url.DeletedAt.Valid {
+		resp.DeletedAt = &url.DeletedAt.Time
+	}
+	return resp
+}
+
+// invalidate drops any cached redirect for the URL with the given ID
+func (s *URLService) invalidate(id uint) {
+	if s.cache != nil {
+		s.cache.Invalidate(id)
+	}
+}
+
+// isDuplicateKeyError reports whether err is a unique constraint violation
+func isDuplicateKeyError(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
 }
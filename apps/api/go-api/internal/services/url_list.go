@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+// Columns a link listing can be sorted by
+const (
+	SortCreatedAt = "created_at"
+	SortClicks    = "clicks"
+	SortClicksAt  = "clicks_at"
+)
+
+const (
+	DefaultURLPageSize = 20
+	MaxURLPageSize     = 100
+)
+
+var ErrInvalidListQuery = errors.New("invalid list query")
+
+// urlCursor is the position of the last link on a page: its sort value and
+// ID, which breaks ties between links sharing a value
+type urlCursor struct {
+	Sort   string     `json:"s"`
+	Time   *time.Time `json:"t,omitempty"`
+	Clicks *int64     `json:"c,omitempty"`
+	ID     uint       `json:"id"`
+}
+
+// GetUserURLs returns one page of the links userID owns
+func (s *URLService) GetUserURLs(ctx context.Context, userID uint, query *models.URLListQuery) ([]models.URLResponse, *models.Pagination, error) {
+	urls, page, err := s.listURLs(ctx, userID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	responses := make([]models.URLResponse, len(urls))
+	for i, url := range urls {
+		responses[i] = *toURLResponse(&url)
+	}
+	if err := s.attachTags(ctx, responses); err != nil {
+		return nil, nil, err
+	}
+
+	return responses, page, nil
+}
+
+// listURLs returns one page of the links owner owns, or of every link if
+// owner is 0
+func (s *URLService) listURLs(ctx context.Context, owner uint, query *models.URLListQuery) ([]models.URL, *models.Pagination, error) {
+	if err := normalizeListQuery(query); err != nil {
+		return nil, nil, err
+	}
+
+	db := s.db.WithContext(ctx)
+	if owner != 0 {
+		db = db.Where("owner = ?", owner)
+	}
+	if !query.CreatedFrom.IsZero() {
+		db = db.Where("created_at >= ?", query.CreatedFrom)
+	}
+	if !query.CreatedTo.IsZero() {
+		db = db.Where("created_at < ?", query.CreatedTo)
+	}
+	if query.MinClicks != nil {
+		db = db.Where("clicks >= ?", *query.MinClicks)
+	}
+	if query.Search != "" {
+		pattern := "%" + escapeLike(query.Search) + "%"
+		db = db.Where("title ILIKE ? OR short_code ILIKE ? OR original_url ILIKE ?", pattern, pattern, pattern)
+	}
+	if query.Tag != "" {
+		if owner != 0 {
+			db = db.Where("id IN (SELECT url_tags.url_id FROM url_tags JOIN tags ON tags.id = url_tags.tag_id WHERE tags.owner = ? AND tags.name = ?)", owner, query.Tag)
+		} else {
+			db = db.Where("id IN (SELECT url_tags.url_id FROM url_tags JOIN tags ON tags.id = url_tags.tag_id WHERE tags.name = ?)", query.Tag)
+		}
+	}
+	if query.FolderID != nil {
+		if *query.FolderID == 0 {
+			db = db.Where("folder_id IS NULL")
+		} else {
+			db = db.Where("folder_id = ?", *query.FolderID)
+		}
+	}
+
+	direction, op := "DESC", "<"
+	if query.Order == "asc" {
+		direction, op = "ASC", ">"
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeURLCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The sort column is one of the constants above, never user input
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", query.Sort, op), cursor.value(), cursor.ID)
+	}
+
+	// One extra row tells whether another page follows
+	var urls []models.URL
+	err := db.Order(fmt.Sprintf("%s %s, id %s", query.Sort, direction, direction)).
+		Limit(query.Limit + 1).
+		Find(&urls).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page := &models.Pagination{Limit: query.Limit}
+	if len(urls) > query.Limit {
+		urls = urls[:query.Limit]
+		page.HasMore = true
+		page.NextCursor = encodeURLCursor(query.Sort, &urls[len(urls)-1])
+	}
+
+	return urls, page, nil
+}
+
+// normalizeListQuery fills in defaults and rejects unknown sorts and
+// impossible ranges
+func normalizeListQuery(query *models.URLListQuery) error {
+	switch {
+	case query.Limit < 0:
+		return fmt.Errorf("%w: limit must be positive", ErrInvalidListQuery)
+	case query.Limit == 0:
+		query.Limit = DefaultURLPageSize
+	case query.Limit > MaxURLPageSize:
+		query.Limit = MaxURLPageSize
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = SortCreatedAt
+	case SortCreatedAt, SortClicks, SortClicksAt:
+	default:
+		return fmt.Errorf("%w: sort must be one of created_at, clicks or clicks_at", ErrInvalidListQuery)
+	}
+
+	query.Order = strings.ToLower(query.Order)
+	switch query.Order {
+	case "":
+		query.Order = "desc"
+	case "asc", "desc":
+	default:
+		return fmt.Errorf("%w: order must be asc or desc", ErrInvalidListQuery)
+	}
+
+	if !query.CreatedFrom.IsZero() && !query.CreatedTo.IsZero() && !query.CreatedFrom.Before(query.CreatedTo) {
+		return fmt.Errorf("%w: created_from must be before created_to", ErrInvalidListQuery)
+	}
+	if query.MinClicks != nil && *query.MinClicks < 0 {
+		return fmt.Errorf("%w: min_clicks must not be negative", ErrInvalidListQuery)
+	}
+	query.Search = strings.TrimSpace(query.Search)
+	query.Tag = strings.ToLower(strings.TrimSpace(query.Tag))
+	return nil
+}
+
+func encodeURLCursor(sort string, url *models.URL) string {
+	cursor := urlCursor{Sort: sort, ID: url.ID}
+	switch sort {
+	case SortClicks:
+		cursor.Clicks = &url.Clicks
+	case SortClicksAt:
+		cursor.Time = &url.ClicksAt
+	default:
+		cursor.Time = &url.CreatedAt
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeURLCursor parses a cursor issued for a listing sorted by sort
+func decodeURLCursor(encoded, sort string) (*urlCursor, error) {
+	invalid := fmt.Errorf("%w: invalid cursor", ErrInvalidListQuery)
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+	var cursor urlCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, invalid
+	}
+	if cursor.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor belongs to a listing sorted by %s", ErrInvalidListQuery, cursor.Sort)
+	}
+	if (sort == SortClicks) != (cursor.Clicks != nil) || (sort != SortClicks) != (cursor.Time != nil) {
+		return nil, invalid
+	}
+	return &cursor, nil
+}
+
+func (c *urlCursor) value() interface{} {
+	if c.Clicks != nil {
+		return *c.Clicks
+	}
+	return *c.Time
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/refsigregory/refurl/apps/api/go-api/internal/models"
+)
+
+func urlRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "original_url", "short_code", "title", "owner", "clicks", "created_at", "clicks_at"})
+}
+
+func TestURLService_GetUserURLs(t *testing.T) {
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("first page", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		mock.ExpectQuery(`SELECT \* FROM "urls" WHERE owner = \$1 AND "urls"\."deleted_at" IS NULL ORDER BY created_at DESC, id DESC LIMIT \$2`).
+			WithArgs(uint(1), 3).
+			WillReturnRows(urlRows().
+				AddRow(3, "https://c.example.com", "ccc", "C", 1, 0, created, created).
+				AddRow(2, "https://b.example.com", "bbb", "B", 1, 0, created, created).
+				AddRow(1, "https://a.example.com", "aaa", "A", 1, 0, created, created))
+		expectURLTags(mock, 3, "launch")
+
+		urls, page, err := service.GetUserURLs(context.Background(), 1, &models.URLListQuery{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, urls, 2)
+		assert.Equal(t, "bbb", urls[1].ShortCode)
+		assert.Equal(t, []string{"launch"}, urls[0].Tags)
+		assert.Nil(t, urls[1].Tags)
+		assert.True(t, page.HasMore)
+		assert.Equal(t, 2, page.Limit)
+
+		cursor, err := decodeURLCursor(page.NextCursor, SortCreatedAt)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), cursor.ID)
+		assert.True(t, created.Equal(cursor.value().(time.Time)))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("filters, search and cursor", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewURLService(db)
+
+		minClicks := int64(5)
+		clicks := int64(40)
+		cursor := encodeURLCursor(SortClicks, &models.URL{ID: 9, Clicks: clicks})
+
+		mock.ExpectQuery(`SELECT \* FROM "urls" WHERE owner = \$1 AND created_at >= \$2 AND created_at < \$3 AND clicks >= \$4 AND \(title ILIKE \$5 OR short_code ILIKE \$6 OR original_url ILIKE \$7\) AND \(clicks, id\) > \(\$8, \$9\) AND "urls"\."deleted_at" IS NULL ORDER BY clicks ASC, id ASC LIMIT \$10`).
+			WithArgs(uint(1), created, created.AddDate(0, 1, 0), minClicks, `%50\%\_off%`, `%50\%\_off%`, `%50\%\_off%`, clicks, uint(9), DefaultURLPageSize+1).
+			WillReturnRows(urlRows().AddRow(4, "https://d.example.com", "ddd", "50%_off", 1, 41, created, created))
+		expectURLTags(mock)
+
+		urls, page, err := service.GetUserURLs(context.Background(), 1, &models.URLListQuery{
+			Cursor:      cursor,
+			Sort:        SortClicks,
+			Order:       "ASC",
+			CreatedFrom: created,
+			CreatedTo:   created.AddDate(0, 1, 0),
+			MinClicks:   &minClicks,
+			Search:      " 50%_off ",
+		})
+		require.NoError(t, err)
+		assert.Len(t, urls, 1)
+		assert.False(t, page.HasMore)
+		assert.Empty(t, page.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestNormalizeListQuery(t *testing.T) {
+	negative := int64(-1)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		query   models.URLListQuery
+		want    models.URLListQuery
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: models.URLListQuery{},
+			want:  models.URLListQuery{Limit: DefaultURLPageSize, Sort: SortCreatedAt, Order: "desc"},
+		},
+		{
+			name:  "limit is capped",
+			query: models.URLListQuery{Limit: 1000, Sort: SortClicksAt, Order: "asc"},
+			want:  models.URLListQuery{Limit: MaxURLPageSize, Sort: SortClicksAt, Order: "asc"},
+		},
+		{name: "negative limit", query: models.URLListQuery{Limit: -1}, wantErr: true},
+		{name: "unknown sort", query: models.URLListQuery{Sort: "title"}, wantErr: true},
+		{name: "unknown order", query: models.URLListQuery{Order: "up"}, wantErr: true},
+		{name: "negative min clicks", query: models.URLListQuery{MinClicks: &negative}, wantErr: true},
+		{name: "inverted range", query: models.URLListQuery{CreatedFrom: now, CreatedTo: now.Add(-time.Hour)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizeListQuery(&tt.query)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidListQuery)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tt.query)
+		})
+	}
+}
+
+func TestDecodeURLCursor(t *testing.T) {
+	cursor := encodeURLCursor(SortCreatedAt, &models.URL{ID: 1, CreatedAt: time.Now()})
+
+	_, err := decodeURLCursor(cursor, SortCreatedAt)
+	assert.NoError(t, err)
+
+	_, err = decodeURLCursor(cursor, SortClicks)
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+
+	_, err = decodeURLCursor("not a cursor", SortCreatedAt)
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
@@ -29,6 +30,13 @@ func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
 	return gormDB, mock
 }
 
+// expectDestinationAllowed expects the blocklist lookup for a destination
+// that is not blocked
+func expectDestinationAllowed(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "blocked_domains"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+}
+
 func TestURLService_CreateURL(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -47,9 +55,10 @@ func TestURLService_CreateURL(t *testing.T) {
 				ShortCode:   "abc123",
 			},
 			mock: func(mock sqlmock.Sqlmock) {
+				expectDestinationAllowed(mock)
 				mock.ExpectBegin()
 				mock.ExpectQuery(`INSERT INTO "urls"`).
-					WithArgs("https://example.com", "abc123", "Example", uint(1), 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WithArgs("https://example.com", "abc123", "Example", uint(1), 0, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, "", false, "", 0, int64(1), nil, nil, nil, "").
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 				mock.ExpectCommit()
 			},
@@ -62,6 +71,112 @@ func TestURLService_CreateURL(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "generated short code",
+			userID: 1,
+			req: &models.CreateURLRequest{
+				OriginalURL: "https://example.com",
+				Title:       "Example",
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				expectDestinationAllowed(mock)
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "urls"`).
+					WithArgs("https://example.com", sqlmock.AnyArg(), "Example", uint(1), 0, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, "", false, "", 0, int64(1), nil, nil, nil, "").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			want: &models.URLResponse{
+				ID:          1,
+				OriginalURL: "https://example.com",
+				Title:       "Example",
+				Clicks:      0,
+			},
+			wantErr: false,
+		},
+		{
+			name:   "generated short code collision is retried",
+			userID: 1,
+			req: &models.CreateURLRequest{
+				OriginalURL: "https://example.com",
+				Title:       "Example",
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				expectDestinationAllowed(mock)
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "urls"`).
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "urls"`).
+					WithArgs("https://example.com", sqlmock.AnyArg(), "Example", uint(1), 0, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, "", false, "", 0, int64(1), nil, nil, nil, "").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectCommit()
+			},
+			want: &models.URLResponse{
+				ID:          2,
+				OriginalURL: "https://example.com",
+				Title:       "Example",
+				Clicks:      0,
+			},
+			wantErr: false,
+		},
+		{
+			name:   "reserved short code",
+			userID: 1,
+			req: &models.CreateURLRequest{
+				OriginalURL: "https://example.com",
+				ShortCode:   "health",
+			},
+			mock:    func(mock sqlmock.Sqlmock) {},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:   "short code taken",
+			userID: 1,
+			req: &models.CreateURLRequest{
+				OriginalURL: "https://example.com",
+				Title:       "Example",
+				ShortCode:   "abc123",
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				expectDestinationAllowed(mock)
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "urls"`).
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+				mock.ExpectRollback()
+				mock.ExpectQuery(`SELECT "short_code" FROM "urls"`).
+					WillReturnRows(sqlmock.NewRows([]string{"short_code"}))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:   "unsupported scheme",
+			userID: 1,
+			req: &models.CreateURLRequest{
+				OriginalURL: "javascript:alert(1)",
+			},
+			mock:    func(mock sqlmock.Sqlmock) {},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:   "blocked fallback url",
+			userID: 1,
+			req: &models.CreateURLRequest{
+				OriginalURL: "https://example.com",
+				FallbackURL: "https://bad.example.net/landing",
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				expectDestinationAllowed(mock)
+				mock.ExpectQuery(`SELECT count\(\*\) FROM "blocked_domains"`).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name:   "database error",
 			userID: 1,
@@ -71,6 +186,7 @@ func TestURLService_CreateURL(t *testing.T) {
 				ShortCode:   "abc123",
 			},
 			mock: func(mock sqlmock.Sqlmock) {
+				expectDestinationAllowed(mock)
 				mock.ExpectBegin()
 				mock.ExpectQuery(`INSERT INTO "urls"`).
 					WillReturnError(gorm.ErrInvalidDB)
@@ -98,8 +214,13 @@ func TestURLService_CreateURL(t *testing.T) {
 			assert.NotNil(t, got)
 			assert.Equal(t, tt.want.OriginalURL, got.OriginalURL)
 			assert.Equal(t, tt.want.Title, got.Title)
-			assert.Equal(t, tt.want.ShortCode, got.ShortCode)
+			if tt.req.ShortCode == "" {
+				assert.Len(t, got.ShortCode, DefaultShortCodeLength)
+			} else {
+				assert.Equal(t, tt.want.ShortCode, got.ShortCode)
+			}
 			assert.Equal(t, tt.want.Clicks, got.Clicks)
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -120,9 +241,10 @@ func TestURLService_GetURLByID(t *testing.T) {
 			mock: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id", "original_url", "title", "short_code", "owner", "clicks", "created_at", "clicks_at"}).
 					AddRow(1, "https://example.com", "Example", "abc123", 1, 0, time.Now(), time.Now())
-				mock.ExpectQuery(`SELECT \* FROM "urls" WHERE id = \$1 AND owner = \$2 ORDER BY "urls"\."id" LIMIT \$3`).
+				mock.ExpectQuery(`SELECT \* FROM "urls" WHERE \(id = \$1 AND owner = \$2\) AND "urls"\."deleted_at" IS NULL ORDER BY "urls"\."id" LIMIT \$3`).
 					WithArgs(1, 1, 1).
 					WillReturnRows(rows)
+				expectURLTags(mock)
 			},
 			want: &models.URLResponse{
 				ID:          1,
@@ -138,8 +260,8 @@ func TestURLService_GetURLByID(t *testing.T) {
 			userID: 1,
 			urlID:  999,
 			mock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(`SELECT \* FROM "urls" WHERE id = \$1 AND owner = \$2`).
-					WithArgs(999, 1).
+				mock.ExpectQuery(`SELECT \* FROM "urls" WHERE \(id = \$1 AND owner = \$2\) AND "urls"\."deleted_at" IS NULL`).
+					WithArgs(sqlmock.AnyArg(), 999, 1).
 					WillReturnError(gorm.ErrRecordNotFound)
 			},
 			want:    nil,
@@ -183,8 +305,8 @@ func TestURLService_DeleteURL(t *testing.T) {
 			urlID:  1,
 			mock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`DELETE FROM "urls" WHERE id = \$1 AND owner = \$2`).
-					WithArgs(1, 1).
+				mock.ExpectExec(`UPDATE "urls" SET "deleted_at"=\$1 WHERE \(id = \$2 AND owner = \$3\) AND "urls"\."deleted_at" IS NULL`).
+					WithArgs(sqlmock.AnyArg(), 1, 1).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
 			},
@@ -196,8 +318,8 @@ func TestURLService_DeleteURL(t *testing.T) {
 			urlID:  999,
 			mock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`DELETE FROM "urls" WHERE id = \$1 AND owner = \$2`).
-					WithArgs(999, 1).
+				mock.ExpectExec(`UPDATE "urls" SET "deleted_at"=\$1 WHERE \(id = \$2 AND owner = \$3\) AND "urls"\."deleted_at" IS NULL`).
+					WithArgs(sqlmock.AnyArg(), 999, 1).
 					WillReturnResult(sqlmock.NewResult(0, 0))
 				mock.ExpectCommit()
 			},
@@ -0,0 +1,136 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	data, err := format("RefURL <no-reply@example.com>", Message{
+		To:      "ann@example.com",
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	}, date)
+	require.NoError(t, err)
+	assert.Equal(t, "From: RefURL <no-reply@example.com>\r\n"+
+		"To: ann@example.com\r\n"+
+		"Subject: Hello\r\n"+
+		"Date: Sun, 18 Oct 2026 12:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"line one\r\nline two", string(data))
+
+	_, err = format("no-reply@example.com", Message{To: "ann@example.com\r\nBcc: eve@example.com"}, date)
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := NewMemoryMailer()
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "ann@example.com", Subject: "One"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "bob@example.com", Subject: "Two"}))
+	assert.ErrorIs(t, m.Send(context.Background(), Message{To: "eve@example.com", Subject: "Three\nBcc: x"}), ErrInvalidHeader)
+
+	messages := m.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "ann@example.com", messages[0].To)
+	assert.Equal(t, "Two", messages[1].Subject)
+}
+
+// serveSMTP answers one SMTP session on l, sending the commands and message
+// it received to sessions once the client quits
+func serveSMTP(l net.Listener, sessions chan<- []string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	var received []string
+	reply := func(line string) bool { return tp.PrintfLine("%s", line) == nil }
+	if !reply("220 localhost ready") {
+		return
+	}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		received = append(received, line)
+		switch {
+		case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "MAIL"), strings.HasPrefix(line, "RCPT"):
+			reply("250 OK")
+		case line == "DATA":
+			reply("354 go ahead")
+			body, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			received = append(received, body...)
+			reply("250 OK")
+		case line == "QUIT":
+			reply("221 bye")
+			sessions <- received
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	sessions := make(chan []string, 1)
+	go serveSMTP(l, sessions)
+
+	port := l.Addr().(*net.TCPAddr).Port
+	m := NewSMTPMailer("127.0.0.1", port, "", "", "RefURL <no-reply@example.com>", time.Second)
+	require.NoError(t, m.Send(context.Background(), Message{To: "ann@example.com", Subject: "Hello", Body: "Hi"}))
+
+	received := <-sessions
+	assert.Contains(t, received, "MAIL FROM:<no-reply@example.com>", "envelope sender is the bare address")
+	assert.Contains(t, received, "RCPT TO:<ann@example.com>")
+	assert.Contains(t, received, "Subject: Hello")
+	assert.Contains(t, received, "Hi")
+}
+
+func TestSMTPMailer_SendTimesOut(t *testing.T) {
+	// The server accepts the connection but never greets the client
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			<-done
+		}
+	}()
+
+	port := l.Addr().(*net.TCPAddr).Port
+	m := NewSMTPMailer("127.0.0.1", port, "", "", "no-reply@example.com", 50*time.Millisecond)
+
+	start := time.Now()
+	err = m.Send(context.Background(), Message{To: "ann@example.com", Subject: "Hello"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, m.Send(ctx, Message{To: "ann@example.com"}), context.Canceled)
+}
@@ -0,0 +1,104 @@
+package useragent
+
+import (
+	"strings"
+)
+
+// Agent is the browser, operating system and device class of a client
+type Agent struct {
+	Browser string
+	OS      string
+	Device  string
+}
+
+const Unknown = "Unknown"
+
+// Device classes
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+type rule struct {
+	token string
+	name  string
+}
+
+// Rules are checked in order, so more specific tokens come first: Edge and
+// Opera also send "Chrome", and Chrome also sends "Safari"
+var (
+	botTokens = []string{"bot", "crawl", "spider", "slurp", "curl/", "wget/", "python-requests", "httpclient"}
+
+	browserRules = []rule{
+		{"edg/", "Edge"},
+		{"edge/", "Edge"},
+		{"opr/", "Opera"},
+		{"opera", "Opera"},
+		{"samsungbrowser/", "Samsung Internet"},
+		{"firefox/", "Firefox"},
+		{"fxios/", "Firefox"},
+		{"crios/", "Chrome"},
+		{"chrome/", "Chrome"},
+		{"msie ", "Internet Explorer"},
+		{"trident/", "Internet Explorer"},
+		{"safari/", "Safari"},
+	}
+
+	osRules = []rule{
+		{"windows", "Windows"},
+		{"iphone", "iOS"},
+		{"ipad", "iOS"},
+		{"ipod", "iOS"},
+		{"android", "Android"},
+		{"cros", "ChromeOS"},
+		{"mac os x", "macOS"},
+		{"macintosh", "macOS"},
+		{"linux", "Linux"},
+	}
+)
+
+// Parse classifies a User-Agent header value
+func Parse(ua string) Agent {
+	lower := strings.ToLower(ua)
+	if lower == "" {
+		return Agent{Browser: Unknown, OS: Unknown, Device: Unknown}
+	}
+
+	agent := Agent{
+		Browser: match(lower, browserRules),
+		OS:      match(lower, osRules),
+		Device:  DeviceDesktop,
+	}
+
+	switch {
+	case containsAny(lower, botTokens):
+		agent.Device = DeviceBot
+	case strings.Contains(lower, "ipad") || strings.Contains(lower, "tablet") ||
+		(strings.Contains(lower, "android") && !strings.Contains(lower, "mobile")):
+		agent.Device = DeviceTablet
+	case strings.Contains(lower, "mobi") || strings.Contains(lower, "iphone") || strings.Contains(lower, "ipod"):
+		agent.Device = DeviceMobile
+	}
+
+	return agent
+}
+
+func match(ua string, rules []rule) string {
+	for _, r := range rules {
+		if strings.Contains(ua, r.token) {
+			return r.name
+		}
+	}
+	return Unknown
+}
+
+func containsAny(s string, tokens []string) bool {
+	for _, t := range tokens {
+		if strings.Contains(s, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes why one field of a request is invalid
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ValidationErrors lists every invalid field of a request
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Reason
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Struct checks the exported fields of the struct v points to against their
+// `validate` tags and returns ValidationErrors naming fields by their JSON
+// name, or nil. Supported rules are required, omitempty, email, url,
+// password, min=N and max=N; min and max count characters for strings and
+// compare the value for numbers. Empty strings and nil pointers are only
+// checked by required.
+func Struct(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: Struct called with %T", v))
+	}
+
+	var errs ValidationErrors
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+		if reason := checkField(rv.Field(i), strings.Split(tag, ",")); reason != "" {
+			errs = append(errs, FieldError{Field: jsonName(field), Reason: reason})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkField returns the reason the first failing rule gives, or ""
+func checkField(value reflect.Value, rules []string) string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if hasRule(rules, "required") {
+				return "is required"
+			}
+			return ""
+		}
+		// A set pointer is present even when it points at a zero value
+		value = value.Elem()
+	} else if value.IsZero() {
+		if hasRule(rules, "required") {
+			return "is required"
+		}
+		if hasRule(rules, "omitempty") || value.Kind() == reflect.String {
+			return ""
+		}
+	}
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		var reason string
+		switch name {
+		case "required", "omitempty":
+		case "email":
+			if ValidateEmail(value.String()) != nil {
+				reason = "must be a valid email address"
+			}
+		case "url":
+			u, err := url.Parse(value.String())
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				reason = "must be an absolute URL"
+			}
+		case "password":
+			if err := ValidatePassword(value.String()); err != nil {
+				reason = err.Error()
+			}
+		case "min", "max":
+			reason = checkBound(value, name, param)
+		default:
+			panic(fmt.Sprintf("validator: unknown rule %q", rule))
+		}
+		if reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func checkBound(value reflect.Value, name, param string) string {
+	limit, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validator: bad %s parameter %q", name, param))
+	}
+
+	var n int64
+	unit := ""
+	switch value.Kind() {
+	case reflect.String:
+		n = int64(utf8.RuneCountInString(value.String()))
+		unit = " characters"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = int64(value.Uint())
+	default:
+		panic(fmt.Sprintf("validator: %s does not apply to %s", name, value.Kind()))
+	}
+
+	if name == "min" && n < limit {
+		if unit != "" {
+			return fmt.Sprintf("must be at least %d%s long", limit, unit)
+		}
+		return fmt.Sprintf("must be at least %d", limit)
+	}
+	if name == "max" && n > limit {
+		if unit != "" {
+			return fmt.Sprintf("must be at most %d%s long", limit, unit)
+		}
+		return fmt.Sprintf("must be at most %d", limit)
+	}
+	return ""
+}
+
+func hasRule(rules []string, name string) bool {
+	for _, rule := range rules {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,74 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRequest struct {
+	Name     string  `json:"name" validate:"required,max=5"`
+	Email    string  `json:"email" validate:"required,email"`
+	Password string  `json:"password" validate:"required,password"`
+	Website  string  `json:"website" validate:"omitempty,url"`
+	Limit    *int64  `json:"limit" validate:"omitempty,min=1"`
+	Note     string  `json:"-" validate:"max=3"`
+	Optional *string `json:"optional"`
+}
+
+func TestStruct(t *testing.T) {
+	zero := int64(0)
+
+	tests := []struct {
+		name string
+		req  testRequest
+		want ValidationErrors
+	}{
+		{
+			name: "valid",
+			req:  testRequest{Name: "Ann", Email: "ann@example.com", Password: "longenough"},
+		},
+		{
+			name: "missing required fields",
+			req:  testRequest{},
+			want: ValidationErrors{
+				{Field: "name", Reason: "is required"},
+				{Field: "email", Reason: "is required"},
+				{Field: "password", Reason: "is required"},
+			},
+		},
+		{
+			name: "every rule failing",
+			req: testRequest{
+				Name:     "Annabelle",
+				Email:    "not-an-email",
+				Password: "short",
+				Website:  "example.com",
+				Limit:    &zero,
+				Note:     "toolong",
+			},
+			want: ValidationErrors{
+				{Field: "name", Reason: "must be at most 5 characters long"},
+				{Field: "email", Reason: "must be a valid email address"},
+				{Field: "password", Reason: ErrInvalidPassword.Error()},
+				{Field: "website", Reason: "must be an absolute URL"},
+				{Field: "limit", Reason: "must be at least 1"},
+				{Field: "Note", Reason: "must be at most 3 characters long"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct(&tt.req)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var got ValidationErrors
+			require.ErrorAs(t, err, &got)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
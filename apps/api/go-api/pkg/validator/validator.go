@@ -6,6 +6,9 @@ import (
 	"strings"
 )
 
+// MinPasswordLength is the shortest account password accepted at registration
+const MinPasswordLength = 8
+
 var (
 	ErrInvalidEmail    = errors.New("invalid email format")
 	ErrInvalidPassword = errors.New("password must be at least 8 characters long")
@@ -32,7 +35,7 @@ func ValidatePassword(password string) error {
 		return ErrEmptyField
 	}
 
-	if len(password) < 8 {
+	if len(password) < MinPasswordLength {
 		return ErrInvalidPassword
 	}
 
@@ -0,0 +1,4 @@
+-- Create "click_events" table
+CREATE TABLE "public"."click_events" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "url_id" bigint NOT NULL, "short_code" character varying(10) NOT NULL, "referrer" text NULL, "user_agent" text NULL, "ip_address" character varying(45) NULL, "accept_language" character varying(255) NULL, "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY ("id"), CONSTRAINT "fk_click_url" FOREIGN KEY ("url_id") REFERENCES "public"."urls" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
+-- Create index "idx_click_events_url_created" to table: "click_events"
+CREATE INDEX "idx_click_events_url_created" ON "public"."click_events" ("url_id", "created_at");
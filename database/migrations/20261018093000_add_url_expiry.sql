@@ -0,0 +1,4 @@
+-- Modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "expires_at" timestamp NULL, ADD COLUMN "max_clicks" bigint NULL, ADD COLUMN "fallback_url" text NULL, ADD COLUMN "expired" boolean NOT NULL DEFAULT false;
+-- Create index "idx_urls_expires_at" to table: "urls"
+CREATE INDEX "idx_urls_expires_at" ON "public"."urls" ("expires_at");
@@ -0,0 +1,2 @@
+-- Modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "password_hash" character varying(255) NULL;
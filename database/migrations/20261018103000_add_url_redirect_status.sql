@@ -0,0 +1,2 @@
+-- Modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "redirect_status" smallint NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Create "blocked_domains" table
+CREATE TABLE "public"."blocked_domains" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "domain" character varying(255) NOT NULL, "reason" text NULL, "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY ("id"));
+-- Create index "idx_blocked_domains_domain" to table: "blocked_domains"
+CREATE UNIQUE INDEX "idx_blocked_domains_domain" ON "public"."blocked_domains" ("domain");
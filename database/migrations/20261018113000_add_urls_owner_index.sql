@@ -0,0 +1,2 @@
+-- Create index "idx_urls_owner_created_at" to table: "urls"
+CREATE INDEX "idx_urls_owner_created_at" ON "public"."urls" ("owner", "created_at", "id");
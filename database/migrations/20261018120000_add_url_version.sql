@@ -0,0 +1,2 @@
+-- Modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
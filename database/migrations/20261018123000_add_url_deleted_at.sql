@@ -0,0 +1,4 @@
+-- Modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "deleted_at" timestamp NULL;
+-- Create index "idx_urls_deleted_at" to table: "urls"
+CREATE INDEX "idx_urls_deleted_at" ON "public"."urls" ("deleted_at");
@@ -0,0 +1,4 @@
+-- Create "url_revisions" table
+CREATE TABLE "public"."url_revisions" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "url_id" bigint NOT NULL, "revision" bigint NOT NULL, "user_id" bigint NULL, "field" character varying(50) NOT NULL, "old_value" text NULL, "new_value" text NULL, "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY ("id"), CONSTRAINT "fk_revision_url" FOREIGN KEY ("url_id") REFERENCES "public"."urls" ("id") ON UPDATE NO ACTION ON DELETE CASCADE, CONSTRAINT "fk_revision_user" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL);
+-- Create index "idx_url_revisions_url_revision" to table: "url_revisions"
+CREATE INDEX "idx_url_revisions_url_revision" ON "public"."url_revisions" ("url_id", "revision");
@@ -0,0 +1,16 @@
+-- Create "folders" table
+CREATE TABLE "public"."folders" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "owner" bigint NOT NULL, "parent_id" bigint NULL, "name" character varying(100) NOT NULL, "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY ("id"), CONSTRAINT "fk_folder_owner" FOREIGN KEY ("owner") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE, CONSTRAINT "fk_folder_parent" FOREIGN KEY ("parent_id") REFERENCES "public"."folders" ("id") ON UPDATE NO ACTION ON DELETE SET NULL);
+-- Create index "idx_folders_owner_parent" to table: "folders"
+CREATE INDEX "idx_folders_owner_parent" ON "public"."folders" ("owner", "parent_id");
+-- Create "tags" table
+CREATE TABLE "public"."tags" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "owner" bigint NOT NULL, "name" character varying(50) NOT NULL, "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY ("id"), CONSTRAINT "fk_tag_owner" FOREIGN KEY ("owner") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
+-- Create index "idx_tags_owner_name" to table: "tags"
+CREATE UNIQUE INDEX "idx_tags_owner_name" ON "public"."tags" ("owner", "name");
+-- Create "url_tags" table
+CREATE TABLE "public"."url_tags" ("url_id" bigint NOT NULL, "tag_id" bigint NOT NULL, PRIMARY KEY ("url_id", "tag_id"), CONSTRAINT "fk_url_tag_url" FOREIGN KEY ("url_id") REFERENCES "public"."urls" ("id") ON UPDATE NO ACTION ON DELETE CASCADE, CONSTRAINT "fk_url_tag_tag" FOREIGN KEY ("tag_id") REFERENCES "public"."tags" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
+-- Create index "idx_url_tags_tag_id" to table: "url_tags"
+CREATE INDEX "idx_url_tags_tag_id" ON "public"."url_tags" ("tag_id");
+-- Modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "folder_id" bigint NULL, ADD CONSTRAINT "fk_url_folder" FOREIGN KEY ("folder_id") REFERENCES "public"."folders" ("id") ON UPDATE NO ACTION ON DELETE SET NULL;
+-- Create index "idx_urls_folder_id" to table: "urls"
+CREATE INDEX "idx_urls_folder_id" ON "public"."urls" ("folder_id");
@@ -0,0 +1,6 @@
+-- Create "idempotency_keys" table
+CREATE TABLE "public"."idempotency_keys" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "owner" bigint NOT NULL, "key" character varying(255) NOT NULL, "request_hash" character(64) NOT NULL, "status_code" integer NOT NULL DEFAULT 0, "response_body" bytea NULL, "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, "expires_at" timestamp NOT NULL, PRIMARY KEY ("id"), CONSTRAINT "fk_idempotency_key_owner" FOREIGN KEY ("owner") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
+-- Create index "idx_idempotency_keys_owner_key" to table: "idempotency_keys"
+CREATE UNIQUE INDEX "idx_idempotency_keys_owner_key" ON "public"."idempotency_keys" ("owner", "key");
+-- Create index "idx_idempotency_keys_expires_at" to table: "idempotency_keys"
+CREATE INDEX "idx_idempotency_keys_expires_at" ON "public"."idempotency_keys" ("expires_at");
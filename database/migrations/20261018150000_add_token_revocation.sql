@@ -0,0 +1,6 @@
+-- Modify "users" table
+ALTER TABLE "public"."users" ADD COLUMN "tokens_revoked_at" timestamp NULL;
+-- Create "revoked_tokens" table
+CREATE TABLE "public"."revoked_tokens" ("jti" character(32) NOT NULL, "user_id" bigint NOT NULL, "expires_at" timestamp NOT NULL, "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY ("jti"), CONSTRAINT "fk_revoked_token_user" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
+-- Create index "idx_revoked_tokens_expires_at" to table: "revoked_tokens"
+CREATE INDEX "idx_revoked_tokens_expires_at" ON "public"."revoked_tokens" ("expires_at");
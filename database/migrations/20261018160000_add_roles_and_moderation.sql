@@ -0,0 +1,4 @@
+-- Modify "users" table
+ALTER TABLE "public"."users" ADD COLUMN "role" character varying(20) NOT NULL DEFAULT 'user', ADD COLUMN "disabled_at" timestamp NULL;
+-- Modify "urls" table
+ALTER TABLE "public"."urls" ADD COLUMN "disabled_at" timestamp NULL, ADD COLUMN "disabled_reason" character varying(255) NOT NULL DEFAULT '';
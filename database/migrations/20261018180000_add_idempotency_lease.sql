@@ -0,0 +1,2 @@
+-- Modify "idempotency_keys" table
+ALTER TABLE "public"."idempotency_keys" ADD COLUMN "locked_until" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -30,6 +30,23 @@ table "users" {
     null = false
     default = sql("CURRENT_TIMESTAMP")
   }
+  column "tokens_revoked_at" {
+    type = timestamp
+    null = true
+  }
+  column "role" {
+    type = varchar(20)
+    null = false
+    default = "user"
+  }
+  column "disabled_at" {
+    type = timestamp
+    null = true
+  }
+  column "email_verified_at" {
+    type = timestamp
+    null = true
+  }
   primary_key {
     columns = [column.id]
   }
@@ -76,6 +93,54 @@ table "urls" {
     null = false
     default = sql("CURRENT_TIMESTAMP")
   }
+  column "expires_at" {
+    type = timestamp
+    null = true
+  }
+  column "max_clicks" {
+    type = bigint
+    null = true
+  }
+  column "fallback_url" {
+    type = text
+    null = true
+  }
+  column "expired" {
+    type = boolean
+    null = false
+    default = false
+  }
+  column "password_hash" {
+    type = varchar(255)
+    null = true
+  }
+  column "redirect_status" {
+    type = smallint
+    null = false
+    default = 0
+  }
+  column "version" {
+    type = bigint
+    null = false
+    default = 1
+  }
+  column "deleted_at" {
+    type = timestamp
+    null = true
+  }
+  column "folder_id" {
+    type = bigint
+    null = true
+  }
+  column "disabled_at" {
+    type = timestamp
+    null = true
+  }
+  column "disabled_reason" {
+    type = varchar(255)
+    null = false
+    default = ""
+  }
   primary_key {
     columns = [column.id]
   }
@@ -84,10 +149,27 @@ table "urls" {
     ref_columns = [table.users.column.id]
     on_delete = SET_NULL
   }
+  foreign_key "fk_url_folder" {
+    columns = [column.folder_id]
+    ref_columns = [table.folders.column.id]
+    on_delete = SET_NULL
+  }
   index "idx_short_code" {
     unique = true
     columns = [column.short_code]
   }
+  index "idx_urls_expires_at" {
+    columns = [column.expires_at]
+  }
+  index "idx_urls_owner_created_at" {
+    columns = [column.owner, column.created_at, column.id]
+  }
+  index "idx_urls_deleted_at" {
+    columns = [column.deleted_at]
+  }
+  index "idx_urls_folder_id" {
+    columns = [column.folder_id]
+  }
 }
 
 table "configs" {
@@ -111,4 +193,433 @@ table "configs" {
     unique = true
     columns = [column.CONFIG_NAME]
   }
-}
\ No newline at end of file
+}
+table "click_events" {
+  schema = schema.public
+  column "id" {
+    type = bigint
+    identity {}
+  }
+  column "url_id" {
+    type = bigint
+    null = false
+  }
+  column "short_code" {
+    type = varchar(10)
+    null = false
+  }
+  column "referrer" {
+    type = text
+    null = true
+  }
+  column "user_agent" {
+    type = text
+    null = true
+  }
+  column "ip_address" {
+    type = varchar(45)
+    null = true
+  }
+  column "accept_language" {
+    type = varchar(255)
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_click_url" {
+    columns = [column.url_id]
+    ref_columns = [table.urls.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_click_events_url_created" {
+    columns = [column.url_id, column.created_at]
+  }
+}
+table "blocked_domains" {
+  schema = schema.public
+  column "id" {
+    type = bigint
+    identity {}
+  }
+  column "domain" {
+    type = varchar(255)
+    null = false
+  }
+  column "reason" {
+    type = text
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  index "idx_blocked_domains_domain" {
+    unique = true
+    columns = [column.domain]
+  }
+}
+table "url_revisions" {
+  schema = schema.public
+  column "id" {
+    type = bigint
+    identity {}
+  }
+  column "url_id" {
+    type = bigint
+    null = false
+  }
+  column "revision" {
+    type = bigint
+    null = false
+  }
+  column "user_id" {
+    type = bigint
+    null = true
+  }
+  column "field" {
+    type = varchar(50)
+    null = false
+  }
+  column "old_value" {
+    type = text
+    null = true
+  }
+  column "new_value" {
+    type = text
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_revision_url" {
+    columns = [column.url_id]
+    ref_columns = [table.urls.column.id]
+    on_delete = CASCADE
+  }
+  foreign_key "fk_revision_user" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = SET_NULL
+  }
+  index "idx_url_revisions_url_revision" {
+    columns = [column.url_id, column.revision]
+  }
+}
+
+table "folders" {
+  schema = schema.public
+  column "id" {
+    type = bigint
+    identity {}
+  }
+  column "owner" {
+    type = bigint
+    null = false
+  }
+  column "parent_id" {
+    type = bigint
+    null = true
+  }
+  column "name" {
+    type = varchar(100)
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_folder_owner" {
+    columns = [column.owner]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  foreign_key "fk_folder_parent" {
+    columns = [column.parent_id]
+    ref_columns = [table.folders.column.id]
+    on_delete = SET_NULL
+  }
+  index "idx_folders_owner_parent" {
+    columns = [column.owner, column.parent_id]
+  }
+}
+
+table "tags" {
+  schema = schema.public
+  column "id" {
+    type = bigint
+    identity {}
+  }
+  column "owner" {
+    type = bigint
+    null = false
+  }
+  column "name" {
+    type = varchar(50)
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_tag_owner" {
+    columns = [column.owner]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_tags_owner_name" {
+    unique = true
+    columns = [column.owner, column.name]
+  }
+}
+
+table "url_tags" {
+  schema = schema.public
+  column "url_id" {
+    type = bigint
+    null = false
+  }
+  column "tag_id" {
+    type = bigint
+    null = false
+  }
+  primary_key {
+    columns = [column.url_id, column.tag_id]
+  }
+  foreign_key "fk_url_tag_url" {
+    columns = [column.url_id]
+    ref_columns = [table.urls.column.id]
+    on_delete = CASCADE
+  }
+  foreign_key "fk_url_tag_tag" {
+    columns = [column.tag_id]
+    ref_columns = [table.tags.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_url_tags_tag_id" {
+    columns = [column.tag_id]
+  }
+}
+
+table "idempotency_keys" {
+  schema = schema.public
+  column "id" {
+    type = bigint
+    identity {}
+  }
+  column "owner" {
+    type = bigint
+    null = false
+  }
+  column "key" {
+    type = varchar(255)
+    null = false
+  }
+  column "request_hash" {
+    type = char(64)
+    null = false
+  }
+  column "status_code" {
+    type = integer
+    null = false
+    default = 0
+  }
+  column "locked_until" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  column "response_body" {
+    type = bytea
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  column "expires_at" {
+    type = timestamp
+    null = false
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_idempotency_key_owner" {
+    columns = [column.owner]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_idempotency_keys_owner_key" {
+    unique = true
+    columns = [column.owner, column.key]
+  }
+  index "idx_idempotency_keys_expires_at" {
+    columns = [column.expires_at]
+  }
+}
+
+table "refresh_tokens" {
+  schema = schema.public
+  column "id" {
+    type = bigint
+    identity {}
+  }
+  column "user_id" {
+    type = bigint
+    null = false
+  }
+  column "family_id" {
+    type = char(32)
+    null = false
+  }
+  column "token_hash" {
+    type = char(64)
+    null = false
+  }
+  column "expires_at" {
+    type = timestamp
+    null = false
+  }
+  column "used_at" {
+    type = timestamp
+    null = true
+  }
+  column "revoked_at" {
+    type = timestamp
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_refresh_token_user" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_refresh_tokens_token_hash" {
+    unique = true
+    columns = [column.token_hash]
+  }
+  index "idx_refresh_tokens_family_id" {
+    columns = [column.family_id]
+  }
+  index "idx_refresh_tokens_user_id" {
+    columns = [column.user_id]
+  }
+}
+
+table "revoked_tokens" {
+  schema = schema.public
+  column "jti" {
+    type = char(32)
+    null = false
+  }
+  column "user_id" {
+    type = bigint
+    null = false
+  }
+  column "expires_at" {
+    type = timestamp
+    null = false
+  }
+  column "created_at" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.jti]
+  }
+  foreign_key "fk_revoked_token_user" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_revoked_tokens_expires_at" {
+    columns = [column.expires_at]
+  }
+}
+
+table "api_keys" {
+  schema = schema.public
+  column "id" {
+    type = bigint
+    identity {}
+  }
+  column "user_id" {
+    type = bigint
+    null = false
+  }
+  column "name" {
+    type = varchar(100)
+    null = false
+  }
+  column "prefix" {
+    type = varchar(16)
+    null = false
+  }
+  column "key_hash" {
+    type = char(64)
+    null = false
+  }
+  column "scopes" {
+    type = varchar(255)
+    null = false
+  }
+  column "last_used_at" {
+    type = timestamp
+    null = true
+  }
+  column "revoked_at" {
+    type = timestamp
+    null = true
+  }
+  column "created_at" {
+    type = timestamp
+    null = false
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_api_key_user" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_api_keys_key_hash" {
+    unique = true
+    columns = [column.key_hash]
+  }
+  index "idx_api_keys_user_id" {
+    columns = [column.user_id]
+  }
+}
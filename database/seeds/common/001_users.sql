@@ -1,5 +1,5 @@
 -- Seed initial users for RefURL application
 
-INSERT INTO users (email, name, password) VALUES
-    ('admin@url.ref.si', 'System Administrator', 'dummypassword'),
-    ('refsi@refsi.si', 'Refsi', 'dummypassword');
+INSERT INTO users (email, name, password, role, email_verified_at) VALUES
+    ('admin@url.ref.si', 'System Administrator', 'dummypassword', 'admin', CURRENT_TIMESTAMP),
+    ('refsi@refsi.si', 'Refsi', 'dummypassword', 'user', CURRENT_TIMESTAMP);